@@ -3,21 +3,26 @@ package graphdb
 import (
     "fmt"
     "strings"
+    "strconv"
     "os"
     "bufio"
+
+    "github.com/yet-another-project/hypergraphdb/element"
+    "github.com/yet-another-project/hypergraphdb/iterator"
 )
 
 type commandsDirector struct {
     commands map[string]Command
-    allNodes map[string]*Node
-    rootNode *Node
+    db *element.Database
+    rootNode *element.Node
     lastPrepared string
     successfulCommands []string
     storeCommand bool
+    stopWatching func()
 }
 
 func NewCommandsDirector() *commandsDirector {
-    dir := &commandsDirector{make(map[string]Command), make(map[string]*Node), nil, "", make([]string, 0), false}
+    dir := &commandsDirector{make(map[string]Command), element.NewDatabase(), nil, "", make([]string, 0), false, nil}
 
     dir.RegisterCommand(&HelpCommand{"help", dir})
     dir.RegisterCommand(&AllCommand{"all", dir})
@@ -26,7 +31,15 @@ func NewCommandsDirector() *commandsDirector {
     dir.RegisterCommand(&NewCommand{"new", dir})
     dir.RegisterCommand(&ReparentCommand{"reparent", dir})
     dir.RegisterCommand(&ConnectCommand{"connect", dir})
+    dir.RegisterCommand(&HyperedgeCommand{"hyperedge", dir})
+    dir.RegisterCommand(&SetCommand{"set", dir})
+    dir.RegisterCommand(&GetCommand{"get", dir})
+    dir.RegisterCommand(&NeighboursCommand{"neighbours", dir})
+    dir.RegisterCommand(&RuleCommand{"rule", dir})
 
+    dir.RegisterCommand(&CheckCommand{"check", dir})
+    dir.RegisterCommand(&HistoryCommand{"history", dir})
+    dir.RegisterCommand(&WatchCommand{"watch", dir})
     dir.RegisterCommand(&SaveCommand{"save", dir})
     dir.RegisterCommand(&LoadCommand{"load", dir})
 
@@ -111,15 +124,12 @@ type GraphCommand struct {
     dir *commandsDirector
 }
 func (cmd *GraphCommand) execute(params []string) bool {
-    var newG *Node
     cmd.dir.storeCommand = true
-    if _, ok := cmd.dir.allNodes[params[0]]; ok {
-        newG = cmd.dir.allNodes[params[0]]
-    } else {
-        newG = NewGraph(params[0])
+    newG := cmd.dir.db.NodeByLabel(params[0])
+    if nil == newG {
+        newG = cmd.dir.db.NewGraph(params[0])
     }
     cmd.dir.rootNode = newG
-    cmd.dir.allNodes[params[0]] = newG
     return true
 }
 func (cmd *GraphCommand) getName() string {
@@ -131,7 +141,7 @@ func (cmd *GraphCommand) getHelp() string {
 }
 func (cmd *GraphCommand) validateParams(params []string) bool {
     if len(params) == 1 {
-        return true
+        return validLabel(params[0])
     }
     return false
 }
@@ -141,8 +151,15 @@ type AllCommand struct {
     dir *commandsDirector
 }
 func (cmd *AllCommand) execute(params []string) bool {
-    for _, node := range cmd.dir.allNodes {
-        fmt.Println(node)
+    types := map[element.NodeType]bool{}
+    for _, param := range params {
+        nodeType, _ := element.ParseNodeType(param)
+        types[nodeType] = true
+    }
+    for _, node := range cmd.dir.db.Nodes() {
+        if len(types) == 0 || types[node.Type()] {
+            fmt.Println(node)
+        }
     }
     return true
 }
@@ -150,14 +167,18 @@ func (cmd *AllCommand) getName() string {
     return cmd.name
 }
 func (cmd *AllCommand) getHelp() string {
-    str := "\n\tprint all nodes"
+    str := "[<type>...]\n\tprint all nodes, or only those of the given types\n"
+    str += "\ttypes: hypergraph, subgraph, node, leaf, hyperedge"
     return str
 }
 func (cmd *AllCommand) validateParams(params []string) bool {
-    if len(params) == 0 {
-        return true
+    for _, param := range params {
+        if _, err := element.ParseNodeType(param); err != nil {
+            fmt.Println(err)
+            return false
+        }
     }
-    return false
+    return true
 }
 
 type NewCommand struct {
@@ -166,8 +187,7 @@ type NewCommand struct {
 }
 func (cmd *NewCommand) execute(params []string) bool {
     cmd.dir.storeCommand = true
-    node := cmd.dir.rootNode.NewSubGraph(params[0])
-    cmd.dir.allNodes[params[0]] = node
+    cmd.dir.rootNode.NewSubGraph(params[0])
     return true
 }
 func (cmd *NewCommand) getName() string {
@@ -179,11 +199,15 @@ func (cmd *NewCommand) getHelp() string {
 }
 func (cmd *NewCommand) validateParams(params []string) bool {
     if len(params) == 1 {
-        if _, ok := cmd.dir.allNodes[params[0]]; ok {
+        if nil == cmd.dir.rootNode {
+            fmt.Println("no active graph, use g <name> first")
+            return false
+        }
+        if nil != cmd.dir.db.NodeByLabel(params[0]) {
             fmt.Println("node '" + params[0] + "' already exists")
             return false
         }
-        return true
+        return validLabel(params[0])
     }
     return false
 }
@@ -195,19 +219,12 @@ type ReparentCommand struct {
 }
 func (cmd *ReparentCommand) execute(params []string) bool {
     cmd.dir.storeCommand = true
-    what := cmd.dir.allNodes[params[0]]
-    to := cmd.dir.allNodes[params[1]]
-    oldParent := what.parent
-    if oldParent != nil {
-        for i, child := range oldParent.subnodes {
-            if child == what {
-                oldParent.subnodes = append(oldParent.subnodes[:i], oldParent.subnodes[i+1:]...)
-                break
-            }
-        }
+    what := cmd.dir.db.NodeByLabel(params[0])
+    to := cmd.dir.db.NodeByLabel(params[1])
+    if !what.MoveTo(to) {
+        fmt.Println("can not move '" + params[0] + "' inside itself")
+        return false
     }
-    what.parent = to
-    to.subnodes = append(to.subnodes, what)
     return true
 }
 func (cmd *ReparentCommand) getName() string {
@@ -219,10 +236,10 @@ func (cmd *ReparentCommand) getHelp() string {
 }
 func (cmd *ReparentCommand) validateParams(params []string) bool {
     if len(params) == 2 {
-        if _, ok := cmd.dir.allNodes[params[0]]; !ok {
+        if nil == cmd.dir.db.NodeByLabel(params[0]) {
             return false
         }
-        if _, ok := cmd.dir.allNodes[params[1]]; !ok {
+        if nil == cmd.dir.db.NodeByLabel(params[1]) {
             return false
         }
         return true
@@ -302,15 +319,10 @@ type DFSCommand struct {
     dir *commandsDirector
 }
 func (cmd *DFSCommand) execute(params []string) bool {
-    start := cmd.dir.allNodes[params[0]]
-    it := NewDFSIterator(start)
-    var prevNode *Node
-    for node := range it.Stream() {
+    start := cmd.dir.db.NodeByLabel(params[0])
+    it := iterator.NewRecursiveDFS(start)
+    for node := range it.All() {
         fmt.Println("\t* " + node.String())
-        if node == prevNode {
-            return false
-        }
-        prevNode = node
     }
     return true
 }
@@ -323,7 +335,7 @@ func (cmd *DFSCommand) getHelp() string {
 }
 func (cmd *DFSCommand) validateParams(params []string) bool {
     if len(params) == 1 {
-        if _, ok := cmd.dir.allNodes[params[0]]; !ok {
+        if nil == cmd.dir.db.NodeByLabel(params[0]) {
             return false
         }
         return true
@@ -337,17 +349,28 @@ type ConnectCommand struct {
 }
 func (cmd *ConnectCommand) execute(params []string) bool {
     cmd.dir.storeCommand = true
-    left := cmd.dir.allNodes[params[0]]
-    right := cmd.dir.allNodes[params[2]]
+    left := cmd.dir.db.NodeByLabel(params[0])
+    right := cmd.dir.db.NodeByLabel(params[2])
+    var edge *element.Edge
     if params[1] == "-" {
-        left.neighbours = append(left.neighbours, right)
-        right.neighbours = append(right.neighbours, left)
+        edge = left.Connect(right, element.Undirected)
     }
     if params[1] == "<" {
-        right.neighbours = append(right.neighbours, left)
+        edge = right.Connect(left, element.Directed)
     }
     if params[1] == ">" {
-        left.neighbours = append(left.neighbours, right)
+        edge = left.Connect(right, element.Directed)
+    }
+    if edge == nil {
+        fmt.Println("nodes are already connected")
+        return false
+    }
+    if len(params) > 3 {
+        edge.SetLabel(params[3])
+    }
+    if len(params) > 4 {
+        weight, _ := strconv.ParseFloat(params[4], 64)
+        edge.SetWeight(weight)
     }
     return true
 }
@@ -355,16 +378,16 @@ func (cmd *ConnectCommand) getName() string {
     return cmd.name
 }
 func (cmd *ConnectCommand) getHelp() string {
-    str := "<node> [<, >, -] <node>\n\tconnect two nodes\n\tthe second parameter tells the direction"
+    str := "<node> [<, >, -] <node> [<label> [<weight>]]\n\tconnect two nodes\n\tthe second parameter tells the direction"
     return str
 }
 func (cmd *ConnectCommand) validateParams(params []string) bool {
-    if len(params) == 3 {
+    if len(params) >= 3 && len(params) <= 5 {
         missing := ""
-        if _, ok := cmd.dir.allNodes[params[0]]; !ok {
+        if nil == cmd.dir.db.NodeByLabel(params[0]) {
             missing = "first"
         }
-        if _, ok := cmd.dir.allNodes[params[2]]; !ok {
+        if nil == cmd.dir.db.NodeByLabel(params[2]) {
             if len(missing) != 0 {
                 missing += " and second"
             } else {
@@ -373,6 +396,13 @@ func (cmd *ConnectCommand) validateParams(params []string) bool {
         }
         if !(params[1] == ">" || params[1] == "<" || params[1] == "-") {
             fmt.Println("connection must be >, < or -")
+            return false
+        }
+        if len(params) == 5 {
+            if _, err := strconv.ParseFloat(params[4], 64); err != nil {
+                fmt.Println("weight must be a number")
+                return false
+            }
         }
         if len(missing) > 6 {
             fmt.Println(missing + " parameters missing")
@@ -388,6 +418,426 @@ func (cmd *ConnectCommand) validateParams(params []string) bool {
     return false
 }
 
+type HyperedgeCommand struct {
+    name string
+    dir *commandsDirector
+}
+func (cmd *HyperedgeCommand) execute(params []string) bool {
+    cmd.dir.storeCommand = true
+    tail := element.NodeSet(nil)
+    head := element.NodeSet(nil)
+    roles := map[*element.Node]string{}
+    side := &tail
+    for _, param := range params[1:] {
+        if param == "->" {
+            side = &head
+            continue
+        }
+        label, role := splitRole(param)
+        node := cmd.dir.db.NodeByLabel(label)
+        *side = append(*side, node)
+        if role != "" {
+            roles[node] = role
+        }
+    }
+    var hyperedge *element.Node
+    if side == &head {
+        hyperedge = cmd.dir.rootNode.ConnectNewDirectedHyperedge(params[0], tail, head)
+    } else {
+        hyperedge = cmd.dir.rootNode.ConnectNewHyperedge(params[0], tail)
+    }
+    if hyperedge == nil {
+        fmt.Println("no common parent to hold '" + params[0] + "'")
+        return false
+    }
+    for node, role := range roles {
+        hyperedge.SetRole(node, role)
+    }
+    return true
+}
+
+// validLabel refuses labels containing ':', which splitRole would
+// take for a role separator.
+func validLabel(label string) bool {
+    if strings.Contains(label, ":") {
+        fmt.Println("'" + label + "': labels can not contain ':'")
+        return false
+    }
+    return true
+}
+
+// splitRole splits a "<node>:<role>" parameter.
+func splitRole(param string) (string, string) {
+    if i := strings.LastIndex(param, ":"); i > 0 {
+        return param[:i], param[i+1:]
+    }
+    return param, ""
+}
+func (cmd *HyperedgeCommand) getName() string {
+    return cmd.name
+}
+func (cmd *HyperedgeCommand) getHelp() string {
+    str := "<name> <node>[:<role>]...\n"
+    str += "hyperedge <name> <node>[:<role>]... -> <node>[:<role>]...\n"
+    str += "\tcreate a hyperedge named <name> going through the given nodes,\n"
+    str += "\tor leading from the nodes before -> to the nodes after it,\n"
+    str += "\teach node playing the optional <role>"
+    return str
+}
+func (cmd *HyperedgeCommand) validateParams(params []string) bool {
+    if len(params) < 2 {
+        fmt.Println("invalid number of parameters")
+        return false
+    }
+    if nil == cmd.dir.rootNode {
+        fmt.Println("no active graph, use g <name> first")
+        return false
+    }
+    if nil != cmd.dir.db.NodeByLabel(params[0]) {
+        fmt.Println("node '" + params[0] + "' already exists")
+        return false
+    }
+    if !validLabel(params[0]) {
+        return false
+    }
+    arrows := 0
+    for i, param := range params[1:] {
+        if param == "->" {
+            arrows++
+            if i == 0 || i == len(params)-2 || arrows > 1 {
+                fmt.Println("-> needs nodes on both sides")
+                return false
+            }
+        } else if label, _ := splitRole(param); nil == cmd.dir.db.NodeByLabel(label) {
+            fmt.Println("node '" + label + "' does not exist")
+            return false
+        }
+    }
+    return true
+}
+
+type SetCommand struct {
+    name string
+    dir *commandsDirector
+}
+func (cmd *SetCommand) execute(params []string) bool {
+    cmd.dir.storeCommand = true
+    node := cmd.dir.db.NodeByLabel(params[0])
+    value, err := cmd.parseValue(params[2:])
+    if err != nil {
+        fmt.Println(err)
+        return false
+    }
+    node.Properties().Set(params[1], value)
+    return true
+}
+func (cmd *SetCommand) parseValue(params []string) (element.Value, error) {
+    if params[0] != "list" {
+        kind, err := element.ParseKind(params[0])
+        if err != nil {
+            return element.Value{}, err
+        }
+        return element.ParseValue(kind, params[1])
+    }
+    kind, err := element.ParseKind(params[1])
+    if err != nil {
+        return element.Value{}, err
+    }
+    items := []element.Value(nil)
+    for _, param := range params[2:] {
+        item, err := element.ParseValue(kind, param)
+        if err != nil {
+            return element.Value{}, err
+        }
+        items = append(items, item)
+    }
+    return element.ListValue(items...), nil
+}
+func (cmd *SetCommand) getName() string {
+    return cmd.name
+}
+func (cmd *SetCommand) getHelp() string {
+    str := "<node> <key> <kind> <value>\n"
+    str += "set <node> <key> list <kind> <value>...\n"
+    str += "\tset a property of <node>\n"
+    str += "\tkinds: string, int, float, bool, bytes (hexadecimal), time (RFC 3339)"
+    return str
+}
+func (cmd *SetCommand) validateParams(params []string) bool {
+    if len(params) < 4 || (len(params) > 4 && params[2] != "list") {
+        fmt.Println("invalid number of parameters")
+        return false
+    }
+    if nil == cmd.dir.db.NodeByLabel(params[0]) {
+        fmt.Println("node '" + params[0] + "' does not exist")
+        return false
+    }
+    return true
+}
+
+type GetCommand struct {
+    name string
+    dir *commandsDirector
+}
+func (cmd *GetCommand) execute(params []string) bool {
+    properties := cmd.dir.db.NodeByLabel(params[0]).Properties()
+    if len(params) == 1 {
+        fmt.Println(properties)
+        return true
+    }
+    value, ok := properties.Get(params[1])
+    if !ok {
+        fmt.Println("property '" + params[1] + "' is not set")
+        return false
+    }
+    fmt.Println(value.Kind().String() + " " + value.String())
+    return true
+}
+func (cmd *GetCommand) getName() string {
+    return cmd.name
+}
+func (cmd *GetCommand) getHelp() string {
+    str := "<node> [<key>]\n\tprint one or all the properties of <node>"
+    return str
+}
+func (cmd *GetCommand) validateParams(params []string) bool {
+    if len(params) == 1 || len(params) == 2 {
+        if nil == cmd.dir.db.NodeByLabel(params[0]) {
+            fmt.Println("node '" + params[0] + "' does not exist")
+            return false
+        }
+        return true
+    }
+    return false
+}
+
+type NeighboursCommand struct {
+    name string
+    dir *commandsDirector
+}
+func (cmd *NeighboursCommand) execute(params []string) bool {
+    node := cmd.dir.db.NodeByLabel(params[0])
+    direction := "all"
+    if len(params) == 2 {
+        direction = params[1]
+    }
+    var neighbours element.NodeSet
+    switch direction {
+    case "out":
+        neighbours = node.OutNeighbours()
+    case "in":
+        neighbours = node.InNeighbours()
+    case "all":
+        neighbours = node.OutNeighbours().Union(node.InNeighbours())
+    default:
+        fmt.Println("invalid direction '" + direction + "'")
+        return false
+    }
+    for _, neighbour := range neighbours {
+        fmt.Println("\t* " + neighbour.Label())
+    }
+    fmt.Println("out: " + strconv.Itoa(node.OutDegree()) + ", in: " + strconv.Itoa(node.InDegree()) + ", degree: " + strconv.Itoa(node.Degree()))
+    return true
+}
+func (cmd *NeighboursCommand) getName() string {
+    return cmd.name
+}
+func (cmd *NeighboursCommand) getHelp() string {
+    str := "<node> [out|in|all]\n\tprint the nodes <node> has an edge to, from, or both, and its degrees"
+    return str
+}
+func (cmd *NeighboursCommand) validateParams(params []string) bool {
+    if len(params) == 1 || len(params) == 2 {
+        if nil == cmd.dir.db.NodeByLabel(params[0]) {
+            fmt.Println("node '" + params[0] + "' does not exist")
+            return false
+        }
+        return true
+    }
+    return false
+}
+
+type CheckCommand struct {
+    name string
+    dir *commandsDirector
+}
+func (cmd *CheckCommand) execute(params []string) bool {
+    violations := cmd.dir.db.Validate()
+    for _, violation := range violations {
+        fmt.Println("\t* " + violation.String())
+    }
+    if len(violations) == 0 {
+        fmt.Println("no violations")
+        return true
+    }
+    if len(params) == 0 {
+        return false
+    }
+    left := cmd.dir.db.Repair()
+    fmt.Println(strconv.Itoa(len(left)) + " violations left after repair")
+    for _, violation := range left {
+        fmt.Println("\t* " + violation.String())
+    }
+    return len(left) == 0
+}
+func (cmd *CheckCommand) getName() string {
+    return cmd.name
+}
+func (cmd *CheckCommand) getHelp() string {
+    str := "[repair]\n\tcheck the consistency of the database and optionally repair it"
+    return str
+}
+func (cmd *CheckCommand) validateParams(params []string) bool {
+    if len(params) == 0 || (len(params) == 1 && params[0] == "repair") {
+        return true
+    }
+    return false
+}
+
+type HistoryCommand struct {
+    name string
+    dir *commandsDirector
+}
+func (cmd *HistoryCommand) execute(params []string) bool {
+    from, to := element.Version(0), cmd.dir.db.Version()
+    if len(params) > 0 {
+        version, _ := strconv.ParseUint(params[0], 10, 64)
+        from = element.Version(version)
+    }
+    if len(params) > 1 {
+        version, _ := strconv.ParseUint(params[1], 10, 64)
+        to = element.Version(version)
+    }
+    for _, change := range cmd.dir.db.Changes(from, to) {
+        fmt.Println("\t* " + change.Time.Format("15:04:05.000") + " " + change.String())
+    }
+    fmt.Println("version " + strconv.FormatUint(uint64(cmd.dir.db.Version()), 10))
+    return true
+}
+func (cmd *HistoryCommand) getName() string {
+    return cmd.name
+}
+func (cmd *HistoryCommand) getHelp() string {
+    str := "[<from> [<to>]]\n\tprint the changes made to the database after version <from> up to version <to>"
+    return str
+}
+func (cmd *HistoryCommand) validateParams(params []string) bool {
+    if len(params) > 2 {
+        return false
+    }
+    for _, param := range params {
+        if _, err := strconv.ParseUint(param, 10, 64); err != nil {
+            fmt.Println("invalid version '" + param + "'")
+            return false
+        }
+    }
+    return true
+}
+
+type WatchCommand struct {
+    name string
+    dir *commandsDirector
+}
+func (cmd *WatchCommand) execute(params []string) bool {
+    if cmd.dir.stopWatching != nil {
+        cmd.dir.stopWatching()
+        cmd.dir.stopWatching = nil
+    }
+    if len(params) == 0 || params[0] == "on" {
+        cmd.dir.stopWatching = cmd.dir.db.Observe(func(change element.Change) {
+            fmt.Println("\t~ " + change.String())
+        })
+    }
+    return true
+}
+func (cmd *WatchCommand) getName() string {
+    return cmd.name
+}
+func (cmd *WatchCommand) getHelp() string {
+    str := "[on|off]\n\tprint every change made to the database as it happens, or stop doing so"
+    return str
+}
+func (cmd *WatchCommand) validateParams(params []string) bool {
+    if len(params) == 0 || (len(params) == 1 && (params[0] == "on" || params[0] == "off")) {
+        return true
+    }
+    return false
+}
+
+type RuleCommand struct {
+    name string
+    dir *commandsDirector
+}
+func (cmd *RuleCommand) execute(params []string) bool {
+    db := cmd.dir.db
+    if len(params) == 0 {
+        for _, rule := range db.Rules() {
+            fmt.Println("\t* " + rule.Name)
+        }
+        return true
+    }
+    cmd.dir.storeCommand = true
+    switch params[1] {
+    case "off":
+        return db.RemoveRule(params[0])
+    case "neighbour":
+        neighbour := db.NodeByLabel(params[2])
+        also := db.NodeByLabel(params[4])
+        return db.AddRule(element.NewNeighbourRule(params[0], neighbour, also))
+    }
+    members := element.NodeSet(nil)
+    for _, label := range params[2:len(params)-2] {
+        members = append(members, db.NodeByLabel(label))
+    }
+    rule, err := element.NewHyperedgeRule(params[0], members, params[len(params)-1])
+    if err != nil {
+        fmt.Println(err)
+        return false
+    }
+    return db.AddRule(rule)
+}
+func (cmd *RuleCommand) getName() string {
+    return cmd.name
+}
+func (cmd *RuleCommand) getHelp() string {
+    str := "<name> neighbour <x> connect <y>\n"
+    str += "rule <name> hyperedge <node>... create <label>\n"
+    str += "rule <name> off\n"
+    str += "rule\n"
+    str += "\tmake the graph change itself: when a node gains the neighbour <x>,\n"
+    str += "\tconnect it to <y> too, or when a hyperedge comes to go through all\n"
+    str += "\tthe given nodes, create a node named <label> next to it unless\n"
+    str += "\tthere is one already;\n"
+    str += "\tremove the rule <name>, or list all the rules"
+    return str
+}
+func (cmd *RuleCommand) validateParams(params []string) bool {
+    var labels []string
+    switch {
+    case len(params) == 0:
+        return true
+    case len(params) == 2 && params[1] == "off":
+        return true
+    case len(params) == 5 && params[1] == "neighbour" && params[3] == "connect":
+        labels = []string{params[2], params[4]}
+    case len(params) >= 5 && params[1] == "hyperedge" && params[len(params)-2] == "create":
+        if !validLabel(params[len(params)-1]) {
+            return false
+        }
+        labels = params[2:len(params)-2]
+    default:
+        fmt.Println("invalid rule")
+        return false
+    }
+    for _, label := range labels {
+        if nil == cmd.dir.db.NodeByLabel(label) {
+            fmt.Println("node '" + label + "' does not exist")
+            return false
+        }
+    }
+    return true
+}
+
 type FooCommand struct {
     name string
     dir *commandsDirector
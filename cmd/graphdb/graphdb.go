@@ -8,7 +8,7 @@ import (
     "github.com/GeertJohan/go.linenoise"
     "github.com/golang/glog"
 
-    "github.com/yet-another-project/hypergraphdb"
+    "github.com/yet-another-project/hypergraphdb/cmd"
 )
 
 func main() {
@@ -0,0 +1,131 @@
+package element_test
+import (
+    "fmt"
+    "sync"
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+// run with -race
+func TestConcurrentUse(t *testing.T) {
+    db := element.NewDatabase()
+    g := db.NewGraph("g")
+    hub := g.NewSubGraph("hub")
+    e := g.ConnectNewHyperedge("e", element.NewNodeSet(hub))
+    var wg sync.WaitGroup
+    for w := 0; w < 4; w++ {
+        wg.Add(1)
+        go func(w int) {
+            defer wg.Done()
+            m := g.NewSubGraph(fmt.Sprint("m", w))
+            for i := 0; i < 50; i++ {
+                n := m.NewSubGraph(fmt.Sprint("n", w, i))
+                hub.Connect(n, element.Directed)
+                e.AddMember(n)
+                e.SetRole(n, "member")
+                n.Properties().Set("i", element.IntValue(int64(i)))
+                if i%3 == 0 {
+                    n.MoveTo(g)
+                }
+                if i%2 == 0 {
+                    hub.Disconnect(n)
+                    e.RemoveMember(n)
+                }
+                if i%5 == 0 {
+                    n.Delete()
+                }
+            }
+        }(w)
+    }
+    for r := 0; r < 4; r++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := 0; i < 50; i++ {
+                for _, n := range hub.Neighbours() {
+                    _ = n.String()
+                    n.Properties().Get("i")
+                }
+                _ = e.Members().String()
+                e.Roles()
+                db.Nodes()
+                db.Validate()
+            }
+        }()
+    }
+    wg.Wait()
+    if violations := db.Validate(); len(violations) > 0 {
+        t.Error("inconsistent after concurrent use", violations)
+    }
+}
+
+// run with -race
+func TestConcurrentSnapshots(t *testing.T) {
+    db := element.NewDatabase()
+    g := db.NewGraph("g")
+    hub := g.NewSubGraph("hub")
+    var wg sync.WaitGroup
+    wg.Add(1)
+    go func() {
+        defer wg.Done()
+        for i := 0; i < 200; i++ {
+            n := g.NewSubGraph(fmt.Sprint("n", i))
+            n.Properties().Set("i", element.IntValue(int64(i)))
+            hub.Connect(n, element.Directed)
+            if i%3 == 0 {
+                hub.Disconnect(n)
+                n.Delete()
+            }
+        }
+    }()
+    for r := 0; r < 4; r++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := 0; i < 50; i++ {
+                snapshot := db.Snapshot()
+                sh := snapshot.Node(hub.ID())
+                neighbours := sh.Neighbours()
+                for _, n := range neighbours {
+                    if _, ok := n.Properties().Get("i"); !ok || n.Parent() == nil {
+                        t.Error("half made node in a snapshot", n)
+                    }
+                }
+                if len(sh.Neighbours()) != len(neighbours) {
+                    t.Error("snapshot changed under its reader")
+                }
+                if violations := snapshot.Validate(); len(violations) > 0 {
+                    t.Error("inconsistent snapshot", violations)
+                }
+            }
+        }()
+    }
+    wg.Wait()
+}
+
+// run with -race: deleting a node and its parent at the same time is fine
+func TestConcurrentDeletes(t *testing.T) {
+    g := element.NewGraph("g")
+    for i := 0; i < 50; i++ {
+        parent := g.NewSubGraph(fmt.Sprint("p", i))
+        child := parent.NewSubGraph(fmt.Sprint("c", i))
+        var wg sync.WaitGroup
+        wg.Add(2)
+        go func() {
+            defer wg.Done()
+            parent.Delete()
+        }()
+        go func() {
+            defer wg.Done()
+            child.Delete()
+            child.NewSubGraph("x")
+        }()
+        wg.Wait()
+    }
+    if "g" != g.String() {
+        t.Error("actual " + g.String())
+    }
+    if 1 != g.Database().Len() {
+        t.Error("expected 1 node, got", g.Database().Len())
+    }
+}
@@ -0,0 +1,254 @@
+package element
+
+import (
+    "slices"
+    "sort"
+    "sync"
+    "weak"
+)
+
+// NodeID identifies a node inside the Database which owns it. IDs are never
+// reused; the zero value means "no node".
+type NodeID uint64
+
+// Database owns a set of nodes, assigns them stable IDs and allows looking
+// them up without holding a pointer.
+// It keeps its own history: every change gets a Version, and AsOf turns the
+// database back into what it was at any of them.
+//
+// A Database and everything in it is safe for concurrent use. One
+// reader/writer lock covers the whole database: reads run in parallel, while
+// each change locks out everything else until the database is consistent
+// again. The sets and slices handed out are never modified afterwards: a
+// change replaces them or appends past their end, and they are handed out
+// clipped to their length, so that appending to them makes a copy. They can
+// be read without holding any lock.
+// The price is that two successive reads may see different states: the
+// iterators read a node's adjacency when they step to it, which makes every
+// step consistent but not a traversal as a whole while writers are busy.
+// Traverse a Snapshot when that matters.
+type Database struct {
+    mu sync.RWMutex
+    readOnly bool //a Snapshot
+    lastID NodeID
+    lastEdgeID EdgeID
+    nodes map[NodeID]*Node
+    edges map[EdgeID]*Edge
+    labels map[string]NodeSet
+    graphs NodeSet
+    history []Change
+    observing sync.Mutex //guards the observers and their delivery
+    observers []*observer
+    delivered Version
+    delivering bool
+    delivery *sync.Cond //signalled as changes get delivered
+    rules ruleSet
+    view *view //what a Snapshot reads its nodes from
+    frozen weak.Pointer[frozen] //where changes are saved for the latest snapshot
+}
+
+func NewDatabase() *Database {
+    db := &Database{
+        nodes: make(map[NodeID]*Node),
+        edges: make(map[EdgeID]*Edge),
+        labels: make(map[string]NodeSet),
+    }
+    db.delivery = sync.NewCond(&db.observing)
+    return db
+}
+
+// NewGraph creates a new top level graph (a node without parent) owned by db.
+func (db *Database) NewGraph(label string) *Node {
+    defer db.lock()()
+    if !db.writable() {
+        return nil
+    }
+    node := newNode(label)
+    db.register(node)
+    db.touchGraphs()
+    db.graphs = append(db.graphs, node)
+    node.recordAdded()
+    return node
+}
+
+func (db *Database) register(node *Node) {
+    db.lastID++
+    node.id = db.lastID
+    node.db = db
+    node.mu = &db.mu
+    node.properties.node = node
+    db.nodes[node.id] = node
+    db.touchLabel(node.label)
+    db.labels[node.label] = append(db.labels[node.label], node)
+}
+
+func (db *Database) unregister(node *Node) {
+    delete(db.nodes, node.id)
+    db.touchLabel(node.label)
+    db.labels[node.label], _ = db.labels[node.label].without(node)
+    if len(db.labels[node.label]) == 0 {
+        delete(db.labels, node.label)
+    }
+    db.touchGraphs()
+    db.graphs, _ = db.graphs.without(node)
+    node.db = nil
+}
+
+func (db *Database) registerEdge(edge *Edge) {
+    db.lastEdgeID++
+    edge.id = db.lastEdgeID
+    edge.mu = &db.mu
+    edge.properties.edge = edge
+    db.edges[edge.id] = edge
+}
+
+func (db *Database) unregisterEdge(edge *Edge) {
+    edge.touch()
+    delete(db.edges, edge.id)
+}
+
+//------------------- lookup
+func (db *Database) Node(id NodeID) *Node {
+    defer db.rlock()()
+    if db.view != nil {
+        return db.view.lookup(id)
+    }
+    return db.nodes[id]
+}
+
+// NodesByLabel returns all the nodes called label, in creation order.
+func (db *Database) NodesByLabel(label string) NodeSet {
+    defer db.rlock()()
+    return db.nodesByLabel(label)
+}
+
+func (db *Database) nodesByLabel(label string) NodeSet {
+    if db.view != nil {
+        return db.view.labelled(label)
+    }
+    return slices.Clip(db.labels[label])
+}
+
+// NodeByLabel returns the first node created with the given label, or nil.
+func (db *Database) NodeByLabel(label string) *Node {
+    defer db.rlock()()
+    if nodes := db.nodesByLabel(label); len(nodes) > 0 {
+        return nodes[0]
+    }
+    return nil
+}
+
+// Graphs returns the top level graphs, in creation order.
+func (db *Database) Graphs() NodeSet {
+    defer db.rlock()()
+    if db.view != nil {
+        return db.view.topLevel()
+    }
+    return slices.Clip(db.graphs)
+}
+
+// Nodes returns every node owned by db, ordered by ID.
+func (db *Database) Nodes() NodeSet {
+    defer db.rlock()()
+    return db.sortedNodes()
+}
+
+func (db *Database) sortedNodes() NodeSet {
+    db.loadAll()
+    nodes := make(NodeSet, 0, len(db.nodes))
+    for _, node := range db.nodes {
+        nodes = append(nodes, node)
+    }
+    sort.Sort(byID(nodes))
+    return nodes
+}
+
+func (db *Database) Edge(id EdgeID) *Edge {
+    defer db.rlock()()
+    if db.view != nil {
+        return db.view.lookupEdge(id)
+    }
+    return db.edges[id]
+}
+
+// Edges returns every edge between nodes of db, ordered by ID.
+func (db *Database) Edges() []*Edge {
+    defer db.rlock()()
+    return db.sortedEdges()
+}
+
+func (db *Database) sortedEdges() []*Edge {
+    db.loadAll()
+    edges := make([]*Edge, 0, len(db.edges))
+    for _, edge := range db.edges {
+        edges = append(edges, edge)
+    }
+    sort.Sort(edgesByID(edges))
+    return edges
+}
+
+func (db *Database) Len() int {
+    defer db.rlock()()
+    db.loadAll()
+    return len(db.nodes)
+}
+
+type byID NodeSet
+
+func (set byID) Len() int { return len(set) }
+func (set byID) Less(i, j int) bool { return set[i].id < set[j].id }
+func (set byID) Swap(i, j int) { set[i], set[j] = set[j], set[i] }
+
+type edgesByID []*Edge
+
+func (edges edgesByID) Len() int { return len(edges) }
+func (edges edgesByID) Less(i, j int) bool { return edges[i].id < edges[j].id }
+func (edges edgesByID) Swap(i, j int) { edges[i], edges[j] = edges[j], edges[i] }
+
+//------------------- locking
+// The lock helpers are deferred in one go: defer db.lock()()
+
+// The write locks notify the observers once they are released.
+func (db *Database) lock() func() {
+    db.mu.Lock()
+    return func() {
+        db.mu.Unlock()
+        db.notify()
+    }
+}
+
+func (db *Database) rlock() func() {
+    db.mu.RLock()
+    return db.mu.RUnlock
+}
+
+func (node *Node) lock() func() {
+    node.mu.Lock()
+    db := node.db
+    return func() {
+        node.mu.Unlock()
+        db.notify()
+    }
+}
+
+func (node *Node) rlock() func() {
+    node.mu.RLock()
+    node.load()
+    return node.mu.RUnlock
+}
+
+// lock locks the database of the first node of set, if any; the nodes of a
+// set are expected to share it.
+func (set NodeSet) lock() func() {
+    if len(set) == 0 {
+        return func() {}
+    }
+    return set[0].lock()
+}
+
+func (set NodeSet) rlock() func() {
+    if len(set) == 0 {
+        return func() {}
+    }
+    return set[0].rlock()
+}
@@ -0,0 +1,122 @@
+package element_test
+import (
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+func TestDatabaseAssignsIDs(t *testing.T) {
+    db := element.NewDatabase()
+    g := db.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewNeighbour("b")
+    h := db.NewGraph("h")
+
+    testData := []*element.Node{g, a, b, h}
+    for i, node := range testData {
+        if element.NodeID(i+1) != node.ID() {
+            t.Error("expected ID", i+1, "for", node.Label(), "got", node.ID())
+        }
+        if db != node.Database() {
+            t.Error(node.Label(), "not owned by the database")
+        }
+        if node != db.Node(node.ID()) {
+            t.Error("lookup by ID failed for", node.Label())
+        }
+    }
+    if 4 != db.Len() {
+        t.Error("expected 4 nodes, got", db.Len())
+    }
+    if nil != db.Node(0) || nil != db.Node(5) {
+        t.Error("expected nil for unknown IDs")
+    }
+}
+
+func TestDatabaseNodesByLabel(t *testing.T) {
+    db := element.NewDatabase()
+    g := db.NewGraph("g")
+    x1 := g.NewSubGraph("x")
+    y := g.NewSubGraph("y")
+    x2 := y.NewSubGraph("x")
+
+    if x1 != db.NodeByLabel("x") {
+        t.Error("expected the first x, got", db.NodeByLabel("x"))
+    }
+    xs := db.NodesByLabel("x")
+    if len(xs) != 2 || xs[0] != x1 || xs[1] != x2 {
+        t.Error("expected both x nodes, got", xs)
+    }
+    if nil != db.NodeByLabel("z") {
+        t.Error("expected nil for unknown label")
+    }
+}
+
+func TestDatabaseGraphsAndNodes(t *testing.T) {
+    db := element.NewDatabase()
+    g := db.NewGraph("g")
+    h := db.NewGraph("h")
+    a := h.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+
+    graphs := db.Graphs()
+    if len(graphs) != 2 || graphs[0] != g || graphs[1] != h {
+        t.Error("expected [g, h], got", graphs)
+    }
+    expected := element.NewNodeSet(g, h, a, b)
+    actual := db.Nodes()
+    if len(actual) != len(expected) {
+        t.Error("expected", expected, "got", actual)
+    }
+    for i := range expected {
+        if expected[i] != actual[i] {
+            t.Error("nodes differ at index", i, "expected", expected[i], "actual", actual[i])
+        }
+    }
+}
+
+func TestNewGraphOwnsDatabase(t *testing.T) {
+    g := element.NewGraph("g")
+    h := element.NewGraph("h")
+    if g.Database() == nil || g.Database() == h.Database() {
+        t.Error("each NewGraph should get its own database")
+    }
+    if g.ID() != h.ID() {
+        t.Error("IDs are per database, expected equal IDs")
+    }
+}
+
+func TestDatabaseDeleteGraph(t *testing.T) {
+    db := element.NewDatabase()
+    g := db.NewGraph("g")
+    g.NewSubGraph("a")
+    h := db.NewGraph("h")
+
+    g.Delete()
+    if 1 != db.Len() || h != db.Nodes()[0] {
+        t.Error("expected only h left, got", db.Nodes())
+    }
+    if len(db.Graphs()) != 1 || h != db.Graphs()[0] {
+        t.Error("expected [h], got", db.Graphs())
+    }
+    if nil != g.Database() {
+        t.Error("deleted node still owned by the database")
+    }
+}
+
+func TestHandedOutSlicesAreClipped(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewNeighbour("b")
+    g.NewSubGraph("c")
+    // appending to what a getter returns must not write into the node
+    subnodes := g.Subnodes()
+    _ = append(subnodes, b)
+    d := g.NewSubGraph("d")
+    if subnodes = g.Subnodes(); len(subnodes) != 4 || subnodes[3] != d {
+        t.Error("expected d as the fourth subnode, got", subnodes)
+    }
+    for _, set := range []element.NodeSet{g.Subnodes(), a.Neighbours(), b.InNeighbours(), g.Database().Graphs()} {
+        if cap(set) != len(set) {
+            t.Error("handed out with room to append", set)
+        }
+    }
+}
@@ -0,0 +1,123 @@
+package element
+
+import (
+    "fmt"
+    "sync"
+)
+
+// EdgeID identifies an edge inside the Database which owns it, like NodeID
+// does for nodes.
+type EdgeID uint64
+
+type Direction int
+
+const (
+    Directed Direction = iota //from -> to, only from has to as neighbour
+    Undirected //both ends have each other as neighbour
+)
+
+// Edge is a regular connection between two nodes. The nodes keep their edges
+// in the same order as their Neighbours.
+type Edge struct {
+    id EdgeID
+    mu *sync.RWMutex //the lock of the database, like for nodes
+    from *Node
+    to *Node
+    direction Direction
+    label string
+    weight float64
+    properties Properties
+}
+
+func (edge *Edge) ID() EdgeID {
+    return edge.id
+}
+
+func (edge *Edge) From() *Node {
+    return edge.from
+}
+
+func (edge *Edge) To() *Node {
+    return edge.to
+}
+
+// Other returns the end of edge which is not node.
+func (edge *Edge) Other(node *Node) *Node {
+    if edge.from == node {
+        return edge.to
+    }
+    return edge.from
+}
+
+func (edge *Edge) Direction() Direction {
+    return edge.direction
+}
+
+func (edge *Edge) Label() string {
+    defer edge.rlock()()
+    return edge.label
+}
+
+func (edge *Edge) SetLabel(label string) {
+    defer edge.lock()()
+    if !edge.from.writable() {
+        return
+    }
+    edge.touch()
+    edge.label = label
+    edge.database().record(Change{Kind: EdgeLabelled, Edge: edge.id, Label: label})
+}
+
+// Weight is 1 unless set otherwise.
+func (edge *Edge) Weight() float64 {
+    defer edge.rlock()()
+    return edge.weight
+}
+
+func (edge *Edge) SetWeight(weight float64) {
+    defer edge.lock()()
+    if !edge.from.writable() {
+        return
+    }
+    edge.touch()
+    edge.weight = weight
+    edge.database().record(Change{Kind: EdgeWeighted, Edge: edge.id, Weight: weight})
+}
+
+func (edge *Edge) Properties() *Properties {
+    return &edge.properties
+}
+
+func (edge *Edge) String() string {
+    defer edge.rlock()()
+    return edge.string()
+}
+
+func (edge *Edge) string() string {
+    arrow := "-"
+    if edge.label != "" {
+        arrow += "[" + edge.label + "]-"
+    }
+    if edge.direction == Directed {
+        arrow += ">"
+    }
+    str := edge.from.label + " " + arrow + " " + edge.to.label
+    if edge.weight != 1 {
+        str += fmt.Sprintf(" (%g)", edge.weight)
+    }
+    return str
+}
+
+func (edge *Edge) lock() func() {
+    edge.mu.Lock()
+    db := edge.from.db
+    return func() {
+        edge.mu.Unlock()
+        db.notify()
+    }
+}
+
+func (edge *Edge) rlock() func() {
+    edge.mu.RLock()
+    return edge.mu.RUnlock
+}
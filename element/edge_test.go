@@ -0,0 +1,150 @@
+package element_test
+import (
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+func TestConnectEdges(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+
+    ab := a.Connect(b, element.Directed)
+    bc := b.Connect(c, element.Undirected)
+    if ab == nil || bc == nil {
+        t.Fatal("expected edges, got", ab, bc)
+    }
+    if a.Connect(b, element.Undirected) != nil {
+        t.Error("a and b are already connected")
+    }
+    if c.Connect(b, element.Directed) != nil {
+        t.Error("c and b are already connected by an undirected edge")
+    }
+    if b.Connect(a, element.Undirected) != nil {
+        t.Error("a already has b as neighbour")
+    }
+    if "a (b)" != a.String() || "b (c)" != b.String() || "c (b)" != c.String() {
+        t.Error("actual", a, b, c)
+    }
+    if ab != a.EdgeTo(b) || nil != b.EdgeTo(a) {
+        t.Error("wrong edges between a and b", a.EdgeTo(b), b.EdgeTo(a))
+    }
+    if bc != b.EdgeTo(c) || bc != c.EdgeTo(b) {
+        t.Error("the undirected edge should lead both ways", b.EdgeTo(c), c.EdgeTo(b))
+    }
+    if c != bc.Other(b) || b != bc.Other(c) {
+        t.Error("wrong ends", bc.Other(b), bc.Other(c))
+    }
+    if len(b.Edges()) != 1 || bc != b.Edges()[0] {
+        t.Error("expected the edges of b to follow its neighbours, got", b.Edges())
+    }
+    db := g.Database()
+    if ab != db.Edge(ab.ID()) || bc != db.Edge(bc.ID()) || len(db.Edges()) != 2 {
+        t.Error("edges not registered", db.Edges())
+    }
+}
+
+func TestEdgeAttributes(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := a.NewNeighbour("c")
+    ab := a.EdgeTo(b)
+    ac := a.EdgeTo(c)
+
+    if 1 != ab.Weight() || "" != ab.Label() || element.Undirected != ab.Direction() {
+        t.Error("wrong defaults", ab.Weight(), ab.Label(), ab.Direction())
+    }
+    if "a - b" != ab.String() || "a -> c" != ac.String() {
+        t.Error("actual", ab, ac)
+    }
+    ab.SetLabel("knows")
+    ab.SetWeight(2.5)
+    ac.SetLabel("owns")
+    if "a -[knows]- b (2.5)" != ab.String() || "a -[owns]-> c" != ac.String() {
+        t.Error("actual", ab, ac)
+    }
+
+    ab.Properties().Set("since", element.IntValue(2010))
+    if value, ok := ab.Properties().Get("since"); !ok || !value.Equal(element.IntValue(2010)) {
+        t.Error("expected 2010, got", value)
+    }
+    if _, ok := ac.Properties().Get("since"); ok {
+        t.Error("properties are per edge")
+    }
+}
+
+func TestDisconnectEdges(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := a.NewNeighbour("c")
+    ab := a.EdgeTo(b)
+    db := g.Database()
+
+    if !b.Disconnect(a) {
+        t.Error("expected success")
+    }
+    if nil != a.EdgeTo(b) || len(b.Edges()) != 0 {
+        t.Error("an undirected edge should disappear from both ends", a, b)
+    }
+    if nil != db.Edge(ab.ID()) {
+        t.Error("edge still registered")
+    }
+    if len(a.Edges()) != 1 || c != a.Edges()[0].To() {
+        t.Error("edges out of sync with neighbours", a.Edges())
+    }
+    c.Delete()
+    if len(a.Edges()) != 0 || len(db.Edges()) != 0 {
+        t.Error("edges of deleted nodes should be gone", a.Edges(), db.Edges())
+    }
+}
+
+func TestIncomingEdges(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    ab := a.Connect(b, element.Directed)
+    cb := c.Connect(b, element.Directed)
+    if b.Connect(a, element.Undirected) != nil {
+        t.Error("b already has a as in-neighbour through a directed edge")
+    }
+    ca := c.Connect(a, element.Undirected)
+
+    assertNodeSet(t, "InNeighbours of b", element.NewNodeSet(a, c), b.InNeighbours())
+    assertNodeSet(t, "InNeighbours of a", element.NewNodeSet(c), a.InNeighbours())
+    assertNodeSet(t, "OutNeighbours of c", element.NewNodeSet(b, a), c.OutNeighbours())
+    assertNodeSet(t, "InNeighbours of c", element.NewNodeSet(a), c.InNeighbours())
+    if ab != b.EdgeFrom(a) || cb != b.EdgeFrom(c) || ca != a.EdgeFrom(c) || ca != c.EdgeFrom(a) {
+        t.Error("wrong incoming edges", b.InEdges(), a.InEdges(), c.InEdges())
+    }
+    if nil != a.EdgeFrom(b) {
+        t.Error("b has no edge to a", a.EdgeFrom(b))
+    }
+
+    testData := []struct {
+        node *element.Node
+        out, in, degree int
+    }{
+        {a, 2, 1, 2},
+        {b, 0, 2, 2},
+        {c, 2, 1, 2},
+    }
+    for _, data := range testData {
+        if data.out != data.node.OutDegree() || data.in != data.node.InDegree() || data.degree != data.node.Degree() {
+            t.Error("wrong degrees of", data.node.Label(), data.node.OutDegree(), data.node.InDegree(), data.node.Degree())
+        }
+    }
+
+    a.Disconnect(b)
+    assertNodeSet(t, "InNeighbours of b after Disconnect", element.NewNodeSet(c), b.InNeighbours())
+    if nil != b.EdgeFrom(a) {
+        t.Error("expected the edge from a to be gone", b.EdgeFrom(a))
+    }
+    c.Delete()
+    if 0 != b.InDegree() || 0 != a.Degree() {
+        t.Error("expected c to be gone from everywhere", b.InNeighbours(), a.InNeighbours(), a.Neighbours())
+    }
+}
@@ -0,0 +1,29 @@
+package element
+
+// The helpers below break the invariants on purpose, to test Validate.
+
+func (node *Node) SetParentUnchecked(parent *Node) {
+    node.parent = parent
+}
+
+func (node *Node) DropSubnodeUnchecked(subnode *Node) {
+    node.subnodes, _ = node.subnodes.without(subnode)
+}
+
+func (node *Node) DropHyperneighbourUnchecked(hyperedge *Node) {
+    node.hyperneighbours, _ = node.hyperneighbours.without(hyperedge)
+}
+
+func (node *Node) AddHyperneighbourUnchecked(hyperedge *Node) {
+    node.hyperneighbours = append(node.hyperneighbours, hyperedge)
+}
+
+func (node *Node) DropEdgeUnchecked(edge *Edge) {
+    node.removeEdge(edge)
+}
+
+func (db *Database) Observers() int {
+    db.observing.Lock()
+    defer db.observing.Unlock()
+    return len(db.observers)
+}
@@ -0,0 +1,332 @@
+package element
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+    "time"
+    "github.com/golang/glog"
+)
+
+// Version counts the changes made to a Database; the empty database is at
+// version 0 and every Change moves it one up.
+type Version uint64
+
+// ChangeKind tells which fields of a Change are set.
+type ChangeKind int
+
+const (
+    NodeAdded ChangeKind = iota //Node with Label under Other, 0 for a graph
+    NodeDeleted //Node with all its subnodes
+    NodeMoved //Node under Other
+    Connected //Edge from Node to Other, in Direction
+    Disconnected //Edge from Node to Other
+    HyperedgeAdded //Node with Label over Members, Ordered, or from Tail to Head
+    MemberAdded //Other into the hyperedge Node at Position, in Role
+    TailAdded //Other into the tail of the hyperedge Node
+    HeadAdded //Other into the head of the hyperedge Node
+    MemberRemoved //Other out of the hyperedge Node, at Position or everywhere
+    RoleSet //Role of Other in the hyperedge Node, at Position or everywhere
+    EdgeLabelled //Label of Edge
+    EdgeWeighted //Weight of Edge
+    PropertySet //Key to Value on Node, or on Edge
+    PropertyDeleted //Key off Node, or off Edge
+)
+
+var changeKindNames = []string{"add", "delete", "move", "connect", "disconnect",
+    "hyperedge", "member", "tail", "head", "unmember", "role", "label",
+    "weight", "set", "unset"}
+
+func (kind ChangeKind) String() string {
+    if kind < 0 || int(kind) >= len(changeKindNames) {
+        return "ChangeKind(" + strconv.Itoa(int(kind)) + ")"
+    }
+    return changeKindNames[kind]
+}
+
+// Change is one step in the history of a Database. The nodes and edges are
+// given by ID, as they may not exist anymore. A Position of -1 stands for
+// every occurrence of Other.
+type Change struct {
+    Version Version
+    Time time.Time
+    Kind ChangeKind
+    Node NodeID
+    Other NodeID
+    Edge EdgeID
+    Label string
+    Direction Direction
+    Members []NodeID
+    Ordered bool
+    Tail []NodeID
+    Head []NodeID
+    Position int
+    Role string
+    Weight float64
+    Key string
+    Value Value
+}
+
+func (change Change) String() string {
+    str := fmt.Sprintf("%d %s", change.Version, change.Kind)
+    switch change.Kind {
+    case NodeAdded:
+        str += fmt.Sprintf(" %d %q", change.Node, change.Label)
+        if change.Other != 0 {
+            str += fmt.Sprintf(" under %d", change.Other)
+        }
+    case NodeDeleted:
+        str += fmt.Sprintf(" %d", change.Node)
+    case NodeMoved:
+        str += fmt.Sprintf(" %d under %d", change.Node, change.Other)
+    case Connected, Disconnected:
+        arrow := "->"
+        if change.Direction == Undirected {
+            arrow = "-"
+        }
+        str += fmt.Sprintf(" edge %d: %d %s %d", change.Edge, change.Node, arrow, change.Other)
+    case HyperedgeAdded:
+        if len(change.Tail) > 0 {
+            str += fmt.Sprintf(" %d %q %v -> %v", change.Node, change.Label, change.Tail, change.Head)
+        } else {
+            str += fmt.Sprintf(" %d %q %v", change.Node, change.Label, change.Members)
+        }
+    case MemberAdded:
+        str += fmt.Sprintf(" %d into %d at %d", change.Other, change.Node, change.Position)
+        if change.Role != "" {
+            str += " as " + change.Role
+        }
+    case TailAdded, HeadAdded:
+        str += fmt.Sprintf(" %d into %d", change.Other, change.Node)
+    case MemberRemoved, RoleSet:
+        if change.Position < 0 {
+            str += fmt.Sprintf(" %d of %d", change.Other, change.Node)
+        } else {
+            str += fmt.Sprintf(" %d of %d at %d", change.Other, change.Node, change.Position)
+        }
+        if change.Kind == RoleSet {
+            str += fmt.Sprintf(" to %q", change.Role)
+        }
+    case EdgeLabelled:
+        str += fmt.Sprintf(" edge %d %q", change.Edge, change.Label)
+    case EdgeWeighted:
+        str += fmt.Sprintf(" edge %d %v", change.Edge, change.Weight)
+    case PropertySet, PropertyDeleted:
+        if change.Edge != 0 {
+            str += fmt.Sprintf(" edge %d", change.Edge)
+        } else {
+            str += fmt.Sprintf(" %d", change.Node)
+        }
+        str += " " + change.Key
+        if change.Kind == PropertySet {
+            str += " " + change.Value.String()
+        }
+    }
+    return str
+}
+
+// record gives change the next version and appends it to the history. Every
+// exported method which changes the database records what it did while it
+// still holds the lock, so the history has the order the changes were made
+// in. Repair records nothing, it undoes damage done outside of the history.
+func (db *Database) record(change Change) {
+    if db == nil {
+        return
+    }
+    change.Version = Version(len(db.history) + 1)
+    change.Time = time.Now()
+    db.history = append(db.history, change)
+}
+
+func (node *Node) recordAdded() {
+    change := Change{Kind: NodeAdded, Node: node.id, Label: node.label}
+    if node.parent != nil {
+        change.Other = node.parent.id
+    }
+    node.db.record(change)
+}
+
+// recordHyperedge passes on nil, for the hyperedges which could not be
+// created.
+func (hyperedge *Node) recordHyperedge() *Node {
+    if hyperedge == nil {
+        return nil
+    }
+    hyperedge.db.record(Change{
+        Kind: HyperedgeAdded,
+        Node: hyperedge.id,
+        Label: hyperedge.label,
+        Members: nodeIDs(hyperedge.hypertrail),
+        Ordered: hyperedge.ordered,
+        Tail: nodeIDs(hyperedge.tail),
+        Head: nodeIDs(hyperedge.head),
+    })
+    return hyperedge
+}
+
+func (edge *Edge) record(kind ChangeKind) {
+    edge.database().record(Change{
+        Kind: kind,
+        Edge: edge.id,
+        Node: edge.from.id,
+        Other: edge.to.id,
+        Direction: edge.direction,
+    })
+}
+
+// database returns the database edge belongs to, or nil once it is removed.
+func (edge *Edge) database() *Database {
+    if db := edge.from.db; db != nil && db.edges[edge.id] == edge {
+        return db
+    }
+    return nil
+}
+
+// Version is the number of changes made to db so far.
+func (db *Database) Version() Version {
+    defer db.rlock()()
+    return Version(len(db.history))
+}
+
+// Changes returns the changes which lead from version from to version to,
+// oldest first.
+func (db *Database) Changes(from Version, to Version) []Change {
+    defer db.rlock()()
+    if to > Version(len(db.history)) {
+        to = Version(len(db.history))
+    }
+    if from >= to {
+        return nil
+    }
+    //the history is only ever appended to
+    return db.history[from:to:to]
+}
+
+// AsOf returns a read-only database as db was at version, which can be
+// traversed like a Snapshot, or nil if db has not reached version yet. It
+// replays the history up to version without holding the lock of db, so it
+// costs as much as all the changes made until then but blocks nobody.
+func (db *Database) AsOf(version Version) *Database {
+    history := db.Changes(0, version)
+    if Version(len(history)) != version {
+        return nil
+    }
+    past := NewDatabase()
+    for _, change := range history {
+        past.apply(change)
+    }
+    past.history = history
+    past.readOnly = true
+    return past
+}
+
+// AsOfTime returns db as it was at t, see AsOf.
+func (db *Database) AsOfTime(t time.Time) *Database {
+    return db.AsOf(db.VersionAt(t))
+}
+
+// VersionAt returns the version db had at t.
+func (db *Database) VersionAt(t time.Time) Version {
+    defer db.rlock()()
+    return Version(sort.Search(len(db.history), func(i int) bool {
+        return db.history[i].Time.After(t)
+    }))
+}
+
+// AsOf returns node as it was at version, or nil if it did not exist then.
+func (node *Node) AsOf(version Version) *Node {
+    db := node.Database()
+    if db == nil {
+        return nil
+    }
+    if past := db.AsOf(version); past != nil {
+        return past.Node(node.id)
+    }
+    return nil
+}
+
+// apply makes change again, on a database which has gone through all the
+// changes before it. Creations get the IDs they had the first time, as IDs
+// are handed out in order.
+func (db *Database) apply(change Change) {
+    node := db.nodes[change.Node]
+    other := db.nodes[change.Other]
+    edge := db.edges[change.Edge]
+    switch change.Kind {
+    case NodeAdded:
+        if other == nil {
+            node = newNode(change.Label)
+            db.register(node)
+            db.graphs = append(db.graphs, node)
+        } else {
+            node = other.newSubGraph(change.Label)
+        }
+    case NodeDeleted:
+        node.remove()
+    case NodeMoved:
+        node.reparent(other)
+        node.realignHyperedges()
+    case Connected:
+        node.connect(other, change.Direction)
+    case Disconnected:
+        edge.remove()
+    case HyperedgeAdded:
+        node = newHyperedge(change.Label, db.nodeSet(change.Members), change.Ordered)
+        node.tail, node.head = db.nodeSet(change.Tail), db.nodeSet(change.Head)
+    case MemberAdded:
+        node.insertMember(change.Position, other, change.Role)
+    case TailAdded:
+        node.addDirected(&node.tail, other)
+    case HeadAdded:
+        node.addDirected(&node.head, other)
+    case MemberRemoved:
+        if change.Position < 0 {
+            node.removeMember(other)
+        } else {
+            node.removeMemberAt(change.Position)
+        }
+    case RoleSet:
+        if change.Position < 0 {
+            node.setRole(other, change.Role)
+        } else {
+            node.setRoleAt(change.Position, change.Role)
+        }
+    case EdgeLabelled:
+        edge.label = change.Label
+    case EdgeWeighted:
+        edge.weight = change.Weight
+    case PropertySet, PropertyDeleted:
+        var properties *Properties
+        if edge != nil {
+            properties = &edge.properties
+        } else {
+            properties = &node.properties
+        }
+        if change.Kind == PropertySet {
+            properties.set(change.Key, change.Value)
+        } else {
+            properties.delete(change.Key)
+        }
+    }
+    if change.Kind == NodeAdded || change.Kind == HyperedgeAdded {
+        if node.id != change.Node {
+            glog.Warningln("version", change.Version, "created", node.id, "instead of", change.Node)
+        }
+    }
+}
+
+func (db *Database) nodeSet(ids []NodeID) NodeSet {
+    nodes := NodeSet(nil)
+    for _, id := range ids {
+        nodes = append(nodes, db.nodes[id])
+    }
+    return nodes
+}
+
+func nodeIDs(nodes NodeSet) []NodeID {
+    ids := []NodeID(nil)
+    for _, node := range nodes {
+        ids = append(ids, node.id)
+    }
+    return ids
+}
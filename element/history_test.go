@@ -0,0 +1,133 @@
+package element_test
+import (
+    "fmt"
+    "testing"
+    "time"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+// dump describes everything a database holds, IDs included.
+func dump(db *element.Database) string {
+    str := ""
+    for _, node := range db.Nodes() {
+        parent := element.NodeID(0)
+        if node.Parent() != nil {
+            parent = node.Parent().ID()
+        }
+        str += fmt.Sprintln(node.ID(), parent, node, node.Roles(), node.Tail(), node.Properties())
+    }
+    for _, edge := range db.Edges() {
+        str += fmt.Sprintln(edge.ID(), edge, edge.Label(), edge.Weight(), edge.Properties())
+    }
+    return str
+}
+
+func TestHistory(t *testing.T) {
+    db := element.NewDatabase()
+    past := map[element.Version]string{0: dump(db)}
+    changes := []func(){}
+    var g, a, b, c, d, e, f *element.Node
+    changes = append(changes,
+        func() { g = db.NewGraph("g") },
+        func() { a = g.NewSubGraph("a") },
+        func() { b = a.NewMutualNeighbour("b") },
+        func() { c = a.NewNeighbour("c") },
+        func() { a.Connect(a, element.Directed) },
+        func() { d = g.NewSubGraph("d") },
+        func() { e = g.ConnectNewOrderedHyperedge("e", element.NewNodeSet(a, b, a)) },
+        func() { e.InsertMemberAs(1, c, "middle") },
+        func() { e.SetRole(a, "end") },
+        func() { e.SetRoleAt(0, "start") },
+        func() { e.RemoveMemberAt(3) },
+        func() { f = g.ConnectNewDirectedHyperedge("f", element.NewNodeSet(a), element.NewNodeSet(e)) },
+        func() { f.AddTail(b) },
+        func() { f.AddHead(c) },
+        func() { c.MoveTo(d) },
+        func() { a.Properties().Set("weight", element.IntValue(3)) },
+        func() { a.EdgeTo(b).Properties().Set("since", element.StringValue("today")) },
+        func() { a.EdgeTo(b).SetLabel("knows") },
+        func() { a.EdgeTo(c).SetWeight(2) },
+        func() { a.Properties().Delete("weight") },
+        func() { f.RemoveMember(b) },
+        func() { a.Disconnect(c) },
+        func() { a.DisconnectMutual(b) },
+        func() { e.RemoveHyperedge() },
+        func() { d.Delete() },
+        func() { g.NewSubGraph("h") },
+    )
+    for _, change := range changes {
+        change()
+        past[db.Version()] = dump(db)
+    }
+    // creating a neighbour takes two changes, a node and an edge
+    if int(db.Version()) != len(changes)+2 {
+        t.Fatal("expected a version per change, got", db.Changes(0, db.Version()))
+    }
+    for version, expected := range past {
+        actual := db.AsOf(version)
+        if actual == nil || !actual.IsReadOnly() {
+            t.Fatal("no read-only database at version", version)
+        }
+        if dump(actual) != expected {
+            t.Errorf("at version %d expected\n%sgot\n%s", version, expected, dump(actual))
+        }
+        if actual.Version() != version {
+            t.Error("wrong version", actual.Version())
+        }
+    }
+    if db.AsOf(db.Version()+1) != nil {
+        t.Error("a version from the future")
+    }
+    if a.AsOf(1) != nil || a.AsOf(2).Label() != "a" {
+        t.Error("expected a from version 2 on")
+    }
+}
+
+func TestChanges(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    a.NewNeighbour("b")
+    g.NewSubGraph("c").Delete()
+    db := g.Database()
+    changes := db.Changes(1, 4)
+    expected := []string{`2 add 2 "a" under 1`, `3 add 3 "b" under 1`, `4 connect edge 1: 2 -> 3`}
+    if len(changes) != len(expected) {
+        t.Fatal("expected", expected, "got", changes)
+    }
+    for i, change := range changes {
+        if change.String() != expected[i] {
+            t.Error("expected", expected[i], "got", change)
+        }
+        if change.Version != element.Version(i+2) || change.Time.IsZero() {
+            t.Error("wrong version or time", change.Version, change.Time)
+        }
+    }
+    if last := db.Changes(5, 10); len(last) != 1 || last[0].Kind != element.NodeDeleted {
+        t.Error("expected the deletion of c, got", last)
+    }
+    if len(db.Changes(4, 4)) != 0 {
+        t.Error("no changes between a version and itself")
+    }
+    // failures are no changes
+    if g.NewNeighbour("x") != nil || a.MoveTo(a) {
+        t.Fatal("expected failures")
+    }
+    if db.Version() != 6 {
+        t.Error("failures got versions", db.Changes(6, 10))
+    }
+}
+
+func TestAsOfTime(t *testing.T) {
+    g := element.NewGraph("g")
+    g.NewSubGraph("a")
+    between := time.Now()
+    time.Sleep(time.Millisecond)
+    g.NewSubGraph("b")
+    db := g.Database()
+    if db.VersionAt(between) != 2 || db.AsOfTime(between).Len() != 2 {
+        t.Error("expected g and a, got", db.AsOfTime(between).Nodes())
+    }
+    if db.AsOfTime(time.Time{}).Len() != 0 {
+        t.Error("expected nothing before the first change")
+    }
+}
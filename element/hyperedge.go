@@ -0,0 +1,432 @@
+package element
+
+import (
+    "slices"
+    "github.com/golang/glog"
+)
+
+// A hyperedge is a node which goes through other nodes, its members. It stays
+// a regular node otherwise: it has a parent, which is kept at the common
+// ancestor of its members, and can have neighbours or subnodes of its own.
+// Its members can be hyperedges too, which allows statements about
+// relationships, as long as no hyperedge ends up among its own members.
+
+// Members returns the nodes hyperedge goes through: a set for unordered
+// hyperedges, a path for ordered ones.
+func (hyperedge *Node) Members() NodeSet {
+    defer hyperedge.rlock()()
+    return slices.Clip(hyperedge.hypertrail)
+}
+
+// IsOrdered tells whether the order of the members of hyperedge matters.
+func (hyperedge *Node) IsOrdered() bool {
+    defer hyperedge.rlock()()
+    return hyperedge.ordered
+}
+
+// IsDirected tells whether hyperedge leads from a tail to a head.
+func (hyperedge *Node) IsDirected() bool {
+    defer hyperedge.rlock()()
+    return hyperedge.isDirected()
+}
+
+func (hyperedge *Node) isDirected() bool {
+    return len(hyperedge.tail) > 0
+}
+
+// Tail returns the nodes a directed hyperedge comes from; it is empty for
+// undirected hyperedges.
+func (hyperedge *Node) Tail() NodeSet {
+    defer hyperedge.rlock()()
+    return slices.Clip(hyperedge.tail)
+}
+
+// Head returns the nodes a directed hyperedge leads to; it is empty for
+// undirected hyperedges.
+func (hyperedge *Node) Head() NodeSet {
+    defer hyperedge.rlock()()
+    return slices.Clip(hyperedge.head)
+}
+
+// DependsOn tells whether other is a member of hyperedge, either directly or
+// as a member of one of the member hyperedges, at any depth.
+func (hyperedge *Node) DependsOn(other *Node) bool {
+    defer hyperedge.rlock()()
+    return hyperedge.dependsOn(other)
+}
+
+func (hyperedge *Node) dependsOn(other *Node) bool {
+    for _, member := range hyperedge.hypertrail {
+        if member == other || member.dependsOn(other) {
+            return true
+        }
+    }
+    return false
+}
+
+// MemberNodes returns the members of hyperedge with every member hyperedge
+// replaced by its own MemberNodes: the nodes a hyperedge is ultimately about.
+func (hyperedge *Node) MemberNodes() NodeSet {
+    defer hyperedge.rlock()()
+    return hyperedge.memberNodes()
+}
+
+func (hyperedge *Node) memberNodes() NodeSet {
+    nodes := NodeSet(nil)
+    for _, member := range hyperedge.hypertrail {
+        if len(member.hypertrail) > 0 {
+            nodes.UnionWith(member.memberNodes())
+        } else {
+            nodes.UnionWith(NewNodeSet(member))
+        }
+    }
+    return nodes
+}
+
+// acceptsMember refuses members from other databases and members which would
+// make hyperedge a member of itself.
+func (hyperedge *Node) acceptsMember(member *Node) bool {
+    if hyperedge.db != member.db {
+        glog.V(1).Infoln(member.label, "belongs to another database than", hyperedge.label)
+        return false
+    }
+    if member == hyperedge || member.dependsOn(hyperedge) {
+        glog.V(1).Infoln(member.label, "would make", hyperedge.label, "a member of itself")
+        return false
+    }
+    return true
+}
+
+// AddTail adds member to the tail of a directed hyperedge. It returns false
+// if hyperedge is not directed or member is already in its tail.
+func (hyperedge *Node) AddTail(member *Node) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    if !hyperedge.addDirected(&hyperedge.tail, member) {
+        return false
+    }
+    hyperedge.db.record(Change{Kind: TailAdded, Node: hyperedge.id, Other: member.id})
+    return true
+}
+
+// AddHead adds member to the head of a directed hyperedge. It returns false
+// if hyperedge is not directed or member is already in its head.
+func (hyperedge *Node) AddHead(member *Node) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    if !hyperedge.addDirected(&hyperedge.head, member) {
+        return false
+    }
+    hyperedge.db.record(Change{Kind: HeadAdded, Node: hyperedge.id, Other: member.id})
+    return true
+}
+
+func (hyperedge *Node) addDirected(side *NodeSet, member *Node) bool {
+    if !hyperedge.isDirected() {
+        glog.V(1).Infoln(hyperedge.label, "is not directed")
+        return false
+    }
+    if !hyperedge.acceptsMember(member) {
+        return false
+    }
+    if _, ok := side.ContainsNode(member); ok {
+        glog.V(1).Infoln(member.label, "is already on that side of", hyperedge.label)
+        return false
+    }
+    hyperedge.touch()
+    *side = append(*side, member)
+    if _, ok := hyperedge.hypertrail.ContainsNode(member); !ok {
+        hyperedge.hypertrail = append(hyperedge.hypertrail, member)
+        hyperedge.roles = append(hyperedge.roles, "")
+        member.touch()
+        member.hyperneighbours = append(member.hyperneighbours, hyperedge)
+    }
+    hyperedge.realign()
+    return true
+}
+
+// AddMember appends member to hyperedge. It returns false if hyperedge is
+// unordered and member already belongs to it, or if hyperedge is directed:
+// use AddTail or AddHead then.
+func (hyperedge *Node) AddMember(member *Node) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    return hyperedge.recordInsert(len(hyperedge.hypertrail), member, "")
+}
+
+// AddMemberAs appends member to hyperedge in the given role.
+func (hyperedge *Node) AddMemberAs(member *Node, role string) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    return hyperedge.recordInsert(len(hyperedge.hypertrail), member, role)
+}
+
+// InsertMember puts member at position i of the members of hyperedge. It
+// returns false if i is out of range or if hyperedge is unordered and member
+// already belongs to it.
+func (hyperedge *Node) InsertMember(i int, member *Node) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    return hyperedge.recordInsert(i, member, "")
+}
+
+// InsertMemberAs puts member at position i in the given role.
+func (hyperedge *Node) InsertMemberAs(i int, member *Node, role string) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    return hyperedge.recordInsert(i, member, role)
+}
+
+func (hyperedge *Node) recordInsert(i int, member *Node, role string) bool {
+    if !hyperedge.insertMember(i, member, role) {
+        return false
+    }
+    hyperedge.db.record(Change{Kind: MemberAdded, Node: hyperedge.id, Other: member.id, Position: i, Role: role})
+    return true
+}
+
+func (hyperedge *Node) insertMember(i int, member *Node, role string) bool {
+    if i < 0 || i > len(hyperedge.hypertrail) {
+        glog.V(1).Infoln("no position", i, "in", hyperedge.label)
+        return false
+    }
+    if hyperedge.isDirected() {
+        glog.V(1).Infoln(hyperedge.label, "is directed, use AddTail or AddHead")
+        return false
+    }
+    if !hyperedge.acceptsMember(member) {
+        return false
+    }
+    _, isMember := hyperedge.hypertrail.ContainsNode(member)
+    if isMember && !hyperedge.ordered {
+        glog.V(1).Infoln(member.label, "is already a member of", hyperedge.label)
+        return false
+    }
+    hyperedge.touch()
+    members := make(NodeSet, 0, len(hyperedge.hypertrail)+1)
+    members = append(members, hyperedge.hypertrail[:i]...)
+    members = append(members, member)
+    hyperedge.hypertrail = append(members, hyperedge.hypertrail[i:]...)
+    roles := make([]string, 0, len(hyperedge.roles)+1)
+    roles = append(roles, hyperedge.roles[:i]...)
+    roles = append(roles, role)
+    hyperedge.roles = append(roles, hyperedge.roles[i:]...)
+    if !isMember {
+        member.touch()
+        member.hyperneighbours = append(member.hyperneighbours, hyperedge)
+    }
+    hyperedge.realign()
+    return true
+}
+
+// RemoveMember takes member out of hyperedge, every occurrence of it for
+// ordered hyperedges and both sides of directed ones. It returns false if
+// member did not belong to hyperedge. A hyperedge left without members is a
+// regular node, a directed one left without tail or head an undirected one.
+func (hyperedge *Node) RemoveMember(member *Node) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    if !hyperedge.removeMember(member) {
+        return false
+    }
+    hyperedge.db.record(Change{Kind: MemberRemoved, Node: hyperedge.id, Other: member.id, Position: -1})
+    return true
+}
+
+func (hyperedge *Node) removeMember(member *Node) bool {
+    if _, ok := hyperedge.hypertrail.ContainsNode(member); !ok {
+        glog.V(1).Infoln(member.label, "is not a member of", hyperedge.label)
+        return false
+    }
+    hyperedge.dropMember(member)
+    member.touch()
+    member.hyperneighbours, _ = member.hyperneighbours.without(hyperedge)
+    hyperedge.realign()
+    return true
+}
+
+func (hyperedge *Node) dropMember(member *Node) {
+    hyperedge.touch()
+    members := NodeSet(nil)
+    roles := []string(nil)
+    for i, localMember := range hyperedge.hypertrail {
+        if localMember != member {
+            members = append(members, localMember)
+            roles = append(roles, hyperedge.roleAt(i))
+        }
+    }
+    hyperedge.hypertrail, hyperedge.roles = members, roles
+    hyperedge.tail, _ = hyperedge.tail.without(member)
+    hyperedge.head, _ = hyperedge.head.without(member)
+    if len(hyperedge.tail) == 0 || len(hyperedge.head) == 0 {
+        hyperedge.tail, hyperedge.head = nil, nil
+    }
+}
+
+// RemoveMemberAt takes out the member at position i. Together with
+// InsertMember it allows reordering the members of ordered hyperedges.
+func (hyperedge *Node) RemoveMemberAt(i int) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    member := hyperedge.removeMemberAt(i)
+    if member == nil {
+        return false
+    }
+    hyperedge.db.record(Change{Kind: MemberRemoved, Node: hyperedge.id, Other: member.id, Position: i})
+    return true
+}
+
+// removeMemberAt returns the member it took out, or nil.
+func (hyperedge *Node) removeMemberAt(i int) *Node {
+    if i < 0 || i >= len(hyperedge.hypertrail) {
+        glog.V(1).Infoln("no position", i, "in", hyperedge.label)
+        return nil
+    }
+    member := hyperedge.hypertrail[i]
+    if hyperedge.isDirected() {
+        hyperedge.removeMember(member)
+        return member
+    }
+    hyperedge.touch()
+    members := append(NodeSet(nil), hyperedge.hypertrail[:i]...)
+    hyperedge.hypertrail = append(members, hyperedge.hypertrail[i+1:]...)
+    roles := append([]string(nil), hyperedge.roles[:i]...)
+    hyperedge.roles = append(roles, hyperedge.roles[i+1:]...)
+    if _, ok := hyperedge.hypertrail.ContainsNode(member); !ok {
+        member.touch()
+        member.hyperneighbours, _ = member.hyperneighbours.without(hyperedge)
+    }
+    hyperedge.realign()
+    return member
+}
+
+//------------------- roles
+// Roles returns the role each of the Members plays, in the same order; the
+// empty string stands for no role.
+func (hyperedge *Node) Roles() []string {
+    defer hyperedge.rlock()()
+    return slices.Clip(hyperedge.roles)
+}
+
+// Role returns the role of member, of its first occurrence for ordered
+// hyperedges. It is empty if member has no role or is not a member.
+func (hyperedge *Node) Role(member *Node) string {
+    defer hyperedge.rlock()()
+    return hyperedge.role(member)
+}
+
+func (hyperedge *Node) role(member *Node) string {
+    if i, ok := hyperedge.hypertrail.ContainsNode(member); ok {
+        return hyperedge.roleAt(i)
+    }
+    return ""
+}
+
+func (hyperedge *Node) RoleAt(i int) string {
+    defer hyperedge.rlock()()
+    return hyperedge.roleAt(i)
+}
+
+func (hyperedge *Node) roleAt(i int) string {
+    if i < 0 || i >= len(hyperedge.roles) {
+        return ""
+    }
+    return hyperedge.roles[i]
+}
+
+// SetRole gives role to every occurrence of member. It returns false if
+// member does not belong to hyperedge.
+func (hyperedge *Node) SetRole(member *Node, role string) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    if !hyperedge.setRole(member, role) {
+        return false
+    }
+    hyperedge.db.record(Change{Kind: RoleSet, Node: hyperedge.id, Other: member.id, Position: -1, Role: role})
+    return true
+}
+
+func (hyperedge *Node) setRole(member *Node, role string) bool {
+    // copy on write: Roles may have handed the old slice out
+    roles := append([]string(nil), hyperedge.roles...)
+    found := false
+    for i, localMember := range hyperedge.hypertrail {
+        if localMember == member {
+            roles[i] = role
+            found = true
+        }
+    }
+    if !found {
+        glog.V(1).Infoln(member.label, "is not a member of", hyperedge.label)
+        return false
+    }
+    hyperedge.touch()
+    hyperedge.roles = roles
+    return true
+}
+
+func (hyperedge *Node) SetRoleAt(i int, role string) bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    if !hyperedge.setRoleAt(i, role) {
+        return false
+    }
+    member := hyperedge.hypertrail[i]
+    hyperedge.db.record(Change{Kind: RoleSet, Node: hyperedge.id, Other: member.id, Position: i, Role: role})
+    return true
+}
+
+func (hyperedge *Node) setRoleAt(i int, role string) bool {
+    if i < 0 || i >= len(hyperedge.roles) {
+        glog.V(1).Infoln("no position", i, "in", hyperedge.label)
+        return false
+    }
+    roles := append([]string(nil), hyperedge.roles...)
+    roles[i] = role
+    hyperedge.touch()
+    hyperedge.roles = roles
+    return true
+}
+
+// MembersByRole returns the members playing role, in order.
+func (hyperedge *Node) MembersByRole(role string) NodeSet {
+    defer hyperedge.rlock()()
+    members := NodeSet(nil)
+    for i, member := range hyperedge.hypertrail {
+        if hyperedge.roleAt(i) == role {
+            members = append(members, member)
+        }
+    }
+    return members
+}
+
+// HyperedgesOf returns the hyperedges node is a member of, or nothing if
+// node does not belong to db.
+func (db *Database) HyperedgesOf(node *Node) NodeSet {
+    defer db.rlock()()
+    if node.db != db {
+        return nil
+    }
+    node.load()
+    return slices.Clip(node.hyperneighbours)
+}
@@ -0,0 +1,232 @@
+package element_test
+import (
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+func TestHyperedgeMembers(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    e := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b, a))
+
+    if e.IsOrdered() {
+        t.Error("expected e to be unordered")
+    }
+    assertNodeSet(t, "Members", element.NewNodeSet(a, b), e.Members())
+    if e.AddMember(a) {
+        t.Error("a is already a member of e")
+    }
+    if !e.AddMember(c) || !e.RemoveMember(a) {
+        t.Error("expected to add c and remove a")
+    }
+    assertNodeSet(t, "Members after editing", element.NewNodeSet(b, c), e.Members())
+    assertNodeSet(t, "HyperedgesOf a", nil, g.Database().HyperedgesOf(a))
+    assertNodeSet(t, "HyperedgesOf c", element.NewNodeSet(e), g.Database().HyperedgesOf(c))
+    if e.RemoveMember(a) {
+        t.Error("a is not a member of e anymore")
+    }
+    if nil != element.NewDatabase().HyperedgesOf(c) {
+        t.Error("c belongs to another database")
+    }
+}
+
+func TestHyperedgePlacement(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewSubGraph("b")
+    c := a.NewSubGraph("c")
+    if e := g.ConnectNewHyperedge("e", element.NewNodeSet(b, c)); e.Parent() != a {
+        t.Error("expected e under the common ancestor a, got", e.Parent())
+    }
+    // not inside its only member, which would take it along when deleted
+    if single := g.ConnectNewHyperedge("single", element.NewNodeSet(b)); single.Parent() != a {
+        t.Error("expected single next to b, got", single.Parent())
+    }
+    if nil != g.ConnectNewHyperedge("empty", nil) {
+        t.Error("expected no hyperedge without members")
+    }
+    h := g.Database().NewGraph("h")
+    if nil != g.ConnectNewHyperedge("apart", element.NewNodeSet(b, h.NewSubGraph("d"))) {
+        t.Error("expected no hyperedge across top level graphs")
+    }
+    if nil != g.ConnectNewHyperedge("top", element.NewNodeSet(g, a)) {
+        t.Error("expected no hyperedge over a top level graph, nothing holds it")
+    }
+}
+
+func TestOrderedHyperedge(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    p := g.ConnectNewOrderedHyperedge("p", element.NewNodeSet(a, b, a))
+
+    if !p.IsOrdered() {
+        t.Error("expected p to be ordered")
+    }
+    assertNodeSet(t, "Members", element.NewNodeSet(a, b, a), p.Members())
+    assertNodeSet(t, "HyperedgesOf a", element.NewNodeSet(p), a.HyperNeighbours())
+    if !p.InsertMember(1, c) || p.InsertMember(5, c) {
+        t.Error("wrong insertion bounds")
+    }
+    assertNodeSet(t, "Members after insertion", element.NewNodeSet(a, c, b, a), p.Members())
+
+    // move b in front
+    if !p.RemoveMemberAt(2) || !p.InsertMember(0, b) {
+        t.Error("expected to move b")
+    }
+    assertNodeSet(t, "Members after reordering", element.NewNodeSet(b, a, c, a), p.Members())
+    assertNodeSet(t, "HyperedgesOf b", element.NewNodeSet(p), b.HyperNeighbours())
+
+    if !p.RemoveMemberAt(1) {
+        t.Error("expected to remove the first a")
+    }
+    assertNodeSet(t, "HyperedgesOf a while on the path", element.NewNodeSet(p), a.HyperNeighbours())
+    if !p.RemoveMember(a) {
+        t.Error("expected to remove a")
+    }
+    assertNodeSet(t, "Members after removal", element.NewNodeSet(b, c), p.Members())
+    assertNodeSet(t, "HyperedgesOf a after removal", nil, a.HyperNeighbours())
+}
+
+func TestHyperedgeFollowsMembers(t *testing.T) {
+    g := element.NewGraph("g")
+    s := g.NewSubGraph("s")
+    a := s.NewSubGraph("a")
+    b := s.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    e := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b))
+
+    if !e.AddMember(c) || g != e.Parent() {
+        t.Error("expected e to move up to g, got", e.Parent())
+    }
+    if !e.RemoveMember(c) || s != e.Parent() {
+        t.Error("expected e to move back to s, got", e.Parent())
+    }
+}
+
+func TestDirectedHyperedge(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    d := g.NewSubGraph("d")
+    r := g.ConnectNewDirectedHyperedge("r", element.NewNodeSet(a, b), element.NewNodeSet(c))
+
+    if !r.IsDirected() || "r <a, b -> c>" != r.String() {
+        t.Error("actual", r)
+    }
+    assertNodeSet(t, "Tail", element.NewNodeSet(a, b), r.Tail())
+    assertNodeSet(t, "Head", element.NewNodeSet(c), r.Head())
+    if nil != g.ConnectNewDirectedHyperedge("x", element.NewNodeSet(g), element.NewNodeSet(a)) {
+        t.Error("expected no hyperedge without a parent to hold it")
+    }
+    assertNodeSet(t, "Members", element.NewNodeSet(a, b, c), r.Members())
+    if r.AddMember(d) || r.AddTail(a) {
+        t.Error("expected AddMember and AddTail to fail")
+    }
+    if !r.AddHead(d) || !r.AddHead(a) {
+        t.Error("expected to add d and a to the head")
+    }
+    assertNodeSet(t, "Head after AddHead", element.NewNodeSet(c, d, a), r.Head())
+    assertNodeSet(t, "Members after AddHead", element.NewNodeSet(a, b, c, d), r.Members())
+
+    if !r.RemoveMember(a) {
+        t.Error("expected to remove a")
+    }
+    assertNodeSet(t, "Tail after RemoveMember", element.NewNodeSet(b), r.Tail())
+    assertNodeSet(t, "Head after RemoveMember", element.NewNodeSet(c, d), r.Head())
+    b.Delete()
+    if r.IsDirected() || "r <c, d>" != r.String() {
+        t.Error("expected r to lose its direction with its tail, got", r)
+    }
+    if nil != g.ConnectNewDirectedHyperedge("x", nil, element.NewNodeSet(c)) {
+        t.Error("a directed hyperedge needs a tail")
+    }
+}
+
+func TestRecursiveHyperedge(t *testing.T) {
+    g := element.NewGraph("g")
+    s := g.NewSubGraph("s")
+    a := s.NewSubGraph("a")
+    b := s.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    likes := g.ConnectNewDirectedHyperedge("likes", element.NewNodeSet(a), element.NewNodeSet(b))
+    knows := g.ConnectNewHyperedge("knows", element.NewNodeSet(c, likes))
+
+    if s != likes.Parent() || g != knows.Parent() {
+        t.Error("wrong placement", likes.Parent(), knows.Parent())
+    }
+    if "knows <c, likes<a -> b>>" != knows.String() {
+        t.Error("actual", knows)
+    }
+    if !knows.DependsOn(a) || !knows.DependsOn(likes) || likes.DependsOn(knows) {
+        t.Error("wrong dependencies")
+    }
+    assertNodeSet(t, "MemberNodes", element.NewNodeSet(c, a, b), knows.MemberNodes())
+    if likes.AddHead(knows) || knows.AddMember(knows) {
+        t.Error("a hyperedge can not be a member of itself")
+    }
+
+    // the outer hyperedge follows the inner one when it moves up
+    nested := g.ConnectNewHyperedge("nested", element.NewNodeSet(a, likes))
+    if s != nested.Parent() {
+        t.Fatal("expected nested under s, got", nested.Parent())
+    }
+    if !likes.AddTail(c) || g != likes.Parent() || g != nested.Parent() {
+        t.Error("expected likes and nested to move up to g", likes.Parent(), nested.Parent())
+    }
+
+    likes.Delete()
+    if "knows <c>" != knows.String() || "nested <a>" != nested.String() {
+        t.Error("actual", knows, nested)
+    }
+    if nil != g.ConnectNewHyperedge("root", element.NewNodeSet(g, a)) {
+        t.Error("there is no place for a hyperedge over the top level graph")
+    }
+}
+
+func TestMemberRoles(t *testing.T) {
+    g := element.NewGraph("g")
+    alice := g.NewSubGraph("alice")
+    bob := g.NewSubGraph("bob")
+    pen := g.NewSubGraph("pen")
+    wrote := g.ConnectNewHyperedge("wrote", element.NewNodeSet(alice, bob))
+
+    if "" != wrote.Role(alice) || !wrote.SetRole(alice, "subject") || !wrote.SetRoleAt(1, "object") {
+        t.Error("expected to set the roles")
+    }
+    if !wrote.AddMemberAs(pen, "instrument") || wrote.SetRole(g, "place") || wrote.SetRoleAt(3, "place") {
+        t.Error("wrong role assignment")
+    }
+    if "wrote <alice:subject, bob:object, pen:instrument>" != wrote.String() {
+        t.Error("actual", wrote)
+    }
+    assertNodeSet(t, "MembersByRole", element.NewNodeSet(bob), wrote.MembersByRole("object"))
+    if "instrument" != wrote.RoleAt(2) || "" != wrote.RoleAt(3) || "object" != wrote.Role(bob) {
+        t.Error("wrong roles", wrote.Roles())
+    }
+    wrote.RemoveMember(alice)
+    if "object" != wrote.RoleAt(0) || 2 != len(wrote.Roles()) {
+        t.Error("expected the roles to follow the members", wrote.Roles())
+    }
+
+    path := g.ConnectNewOrderedHyperedge("path", element.NewNodeSet(alice, bob, alice))
+    path.SetRoleAt(0, "from")
+    path.SetRoleAt(2, "to")
+    path.InsertMemberAs(1, pen, "via")
+    if "path <alice:from, pen:via, bob, alice:to>" != path.String() {
+        t.Error("actual", path)
+    }
+    if !path.RemoveMemberAt(0) || "path <pen:via, bob, alice:to>" != path.String() {
+        t.Error("actual", path)
+    }
+
+    r := g.ConnectNewDirectedHyperedge("r", element.NewNodeSet(alice, bob), element.NewNodeSet(pen))
+    r.SetRole(pen, "product")
+    if "r <alice, bob -> pen:product>" != r.String() {
+        t.Error("actual", r)
+    }
+}
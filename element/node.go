@@ -1,14 +1,25 @@
 package element
 
-import "github.com/golang/glog"
+import (
+    "errors"
+    "slices"
+    "strconv"
+    "sync"
+    "sync/atomic"
+    "github.com/golang/glog"
+)
 
+// Node is safe for concurrent use: all the nodes of a Database share its
+// lock, so every method sees and leaves the whole database consistent. The
+// Show* display flags are not synchronised, set them before sharing a node.
 type Node struct {
+    id NodeID
+    db *Database
+    mu *sync.RWMutex //the lock of db, kept after the node gets deleted
     label string
-    parent *Node
-    subnodes NodeSet //nested subgraphs
-    neighbours NodeSet //connected nodes (regular nodes, regular edges)
-    hypertrail NodeSet //the nodes this node goes through as a hyperedge
-    hyperneighbours NodeSet //the hypertrails that go through this node
+    links
+    properties Properties
+    unloaded atomic.Bool //a Snapshot node which has not been read yet
 
     ShowNeighbours bool
     ShowHypertrail bool
@@ -16,80 +27,462 @@ type Node struct {
     ShowHyperNeighbours bool
 }
 
-type NodeType int //TODO: infer this
+// links is what ties a node to the others. The slices in there are appended
+// to or replaced, never changed within their length, so saving the links of
+// a node for a Snapshot only takes copying them.
+type links struct {
+    parent *Node
+    subnodes NodeSet //nested subgraphs
+    neighbours NodeSet //connected nodes (regular nodes, regular edges)
+    edges []*Edge //the edges leading to neighbours, in the same order
+    inNeighbours NodeSet //nodes having this node as neighbour
+    inEdges []*Edge //the edges coming from inNeighbours, in the same order
+    hypertrail NodeSet //the nodes this node goes through as a hyperedge
+    hyperneighbours NodeSet //the hypertrails that go through this node
+    roles []string //the role of each hypertrail node, in the same order
+    ordered bool //the hypertrail is a sequence rather than a set
+    tail NodeSet //the hypertrail nodes a directed hyperedge comes from
+    head NodeSet //the hypertrail nodes a directed hyperedge leads to
+}
+
+// NodeType classifies a node by its structure, see Node.Type.
+type NodeType int
 
 const (
     Hypergraph NodeType = iota //no parent
     Leaf //no subnodes, neighbours or hypertrails
     GraphNode //no subnodes and hypertrails
+    HyperedgeNode //has a hypertrail
+    SubGraph //has subnodes
 )
 
+var nodeTypeNames = []string{"hypergraph", "leaf", "node", "hyperedge", "subgraph"}
+
+func (nodeType NodeType) String() string {
+    if nodeType < 0 || int(nodeType) >= len(nodeTypeNames) {
+        return "NodeType(" + strconv.Itoa(int(nodeType)) + ")"
+    }
+    return nodeTypeNames[nodeType]
+}
+
+// ParseNodeType is the reverse of NodeType.String.
+func ParseNodeType(name string) (NodeType, error) {
+    for nodeType, typeName := range nodeTypeNames {
+        if typeName == name {
+            return NodeType(nodeType), nil
+        }
+    }
+    return 0, errors.New("unknown node type " + name)
+}
+
+// NewGraph creates a graph in a new, empty Database.
 func NewGraph(label string) *Node {
-    return &Node{label, nil, NodeSet(nil), NodeSet(nil), NodeSet(nil), NodeSet(nil), true, true, true, true}
+    return NewDatabase().NewGraph(label)
+}
+
+func newNode(label string) *Node {
+    return &Node{
+        label: label,
+        ShowNeighbours: true,
+        ShowHypertrail: true,
+        ShowSubnodes: true,
+        ShowHyperNeighbours: true,
+    }
 }
 
 func (parent *Node) NewSubGraph(label string) *Node {
-    newNode := NewGraph(label)
+    defer parent.lock()()
+    if !parent.writable() {
+        return nil
+    }
+    newNode := parent.newSubGraph(label)
+    newNode.recordAdded()
+    return newNode
+}
+
+func (parent *Node) newSubGraph(label string) *Node {
+    newNode := newNode(label)
+    parent.db.register(newNode)
     newNode.ShowNeighbours = parent.ShowNeighbours
     newNode.ShowHypertrail = parent.ShowHypertrail
     newNode.ShowSubnodes = parent.ShowSubnodes
     newNode.parent = parent
+    parent.touch()
     parent.subnodes = append(parent.subnodes, newNode)
     return newNode
 }
 
 func (node *Node) NewNeighbour(label string) *Node {
+    defer node.lock()()
+    if !node.writable() {
+        return nil
+    }
     if nil == node.parent {
         return nil
     }
-    newNode := node.parent.NewSubGraph(label)
-    node.neighbours = append(node.neighbours, newNode)
+    newNode := node.parent.newSubGraph(label)
+    newNode.recordAdded()
+    node.connect(newNode, Directed).record(Connected)
     return newNode
 }
 
 func (node *Node) NewMutualNeighbour(label string) *Node {
-    newNode := node.NewNeighbour(label)
-    if nil == newNode {
+    defer node.lock()()
+    if !node.writable() {
         return nil
     }
-    newNode.ConnectNeighbour(node)
+    if nil == node.parent {
+        return nil
+    }
+    newNode := node.parent.newSubGraph(label)
+    newNode.recordAdded()
+    node.connect(newNode, Undirected).record(Connected)
     return newNode
 }
 
+// Connect creates an edge from node to other. It returns nil if other is
+// already a neighbour of node or, for undirected edges, the other way round,
+// and if the two nodes belong to different databases.
+func (node *Node) Connect(other *Node, direction Direction) *Edge {
+    defer node.lock()()
+    if !node.writable() {
+        return nil
+    }
+    if other.db != node.db {
+        glog.V(1).Infoln(other.label + " belongs to another database than " + node.label)
+        return nil
+    }
+    edge := node.connect(other, direction)
+    if edge != nil {
+        edge.record(Connected)
+    }
+    return edge
+}
+
+func (node *Node) connect(other *Node, direction Direction) *Edge {
+    if _, ok := node.neighbours.ContainsNode(other); ok {
+        glog.V(1).Infoln(other.label + " is already a neighbour of " + node.label)
+        return nil
+    }
+    if _, ok := other.neighbours.ContainsNode(node); ok && direction == Undirected {
+        glog.V(1).Infoln(node.label + " is already a neighbour of " + other.label)
+        return nil
+    }
+    edge := &Edge{from: node, to: other, direction: direction, weight: 1}
+    node.db.registerEdge(edge)
+    node.addEdge(edge)
+    other.addInEdge(edge)
+    if direction == Undirected && other != node {
+        other.addEdge(edge)
+        node.addInEdge(edge)
+    }
+    return edge
+}
+
+func (node *Node) addEdge(edge *Edge) {
+    node.touch()
+    node.edges = append(node.edges, edge)
+    node.neighbours = append(node.neighbours, edge.Other(node))
+}
+
+func (node *Node) addInEdge(edge *Edge) {
+    node.touch()
+    node.inEdges = append(node.inEdges, edge)
+    node.inNeighbours = append(node.inNeighbours, edge.Other(node))
+}
+
 func (node *Node) ConnectNeighbour(other *Node) bool {
-    for _, neighbour := range node.neighbours {
-        if neighbour == other {
-            glog.V(1).Infoln(other.String() + " is already a neighbour of " + node.String())
-            return false
+    return node.Connect(other, Directed) != nil
+}
+
+// ConnectMutualNeighbour connects the two nodes with an undirected edge.
+func (node *Node) ConnectMutualNeighbour(other *Node) bool {
+    return node.Connect(other, Undirected) != nil
+}
+
+// ConnectNewHyperedge creates an unordered hyperedge: its members form a set,
+// so each node belongs to it once, duplicates in set are dropped, and their
+// order carries no meaning. The hyperedge becomes a subnode of the common
+// ancestor of its members; a hyperedge with a single member goes next to it,
+// under its parent, rather than inside it. It returns nil if there is no
+// such node, as for an empty set or members in different top level graphs.
+func (node *Node) ConnectNewHyperedge(label string, set NodeSet) *Node {
+    defer set.lock()()
+    return newHyperedge(label, set.Unique(), false).recordHyperedge()
+}
+
+// ConnectNewOrderedHyperedge creates an ordered hyperedge: its members form a
+// path, which may go through the same node more than once.
+func (node *Node) ConnectNewOrderedHyperedge(label string, path NodeSet) *Node {
+    defer path.lock()()
+    return newHyperedge(label, append(NodeSet(nil), path...), true).recordHyperedge()
+}
+
+// ConnectNewDirectedHyperedge creates a hyperedge leading from all the nodes of
+// tail to all the nodes of head, e.g. a reaction {a, b} -> {c}. A node may
+// be in both. It returns nil if tail or head is empty.
+func (node *Node) ConnectNewDirectedHyperedge(label string, tail NodeSet, head NodeSet) *Node {
+    if len(tail) == 0 || len(head) == 0 {
+        glog.V(1).Infoln("a directed hyperedge needs both a tail and a head")
+        return nil
+    }
+    defer tail.lock()()
+    hyperedge := newHyperedge(label, tail.Union(head), false)
+    if hyperedge == nil {
+        return nil
+    }
+    hyperedge.tail = tail.Unique()
+    hyperedge.head = head.Unique()
+    return hyperedge.recordHyperedge()
+}
+
+// newHyperedge returns nil if members have no common ancestor to hold the
+// hyperedge, e.g. if one of them is a top level graph.
+func newHyperedge(label string, members NodeSet, ordered bool) *Node {
+    for _, member := range members {
+        if member.db != members[0].db {
+            glog.V(1).Infoln("the members of", label, "belong to different databases")
+            return nil
         }
     }
-    node.neighbours = append(node.neighbours, other)
+    if len(members) > 0 && !members[0].writable() {
+        return nil
+    }
+    parent := hyperedgeParent(members)
+    if parent == nil {
+        glog.V(1).Infoln("no common ancestor to hold", label)
+        return nil
+    }
+    hyperedge := parent.newSubGraph(label)
+    hyperedge.hypertrail = members
+    hyperedge.roles = make([]string, len(members))
+    hyperedge.ordered = ordered
+    for _, member := range members.Unique() {
+        member.touch()
+        member.hyperneighbours = append(member.hyperneighbours, hyperedge)
+    }
+    return hyperedge
+}
+
+//------------------- moving
+// MoveTo makes node, with all its subnodes, a subnode of newParent. It
+// refuses to move a node under itself or one of its descendants, which would
+// turn the containment into a cycle, and to move it to another Database.
+// Hyperedges going through the moved nodes then get moved back under the
+// CommonAncestor of their hypertrail.
+func (node *Node) MoveTo(newParent *Node) bool {
+    defer node.lock()()
+    if !node.writable() {
+        return false
+    }
+    if newParent.db != node.db {
+        glog.V(1).Infoln(newParent.label + " belongs to another database than " + node.label)
+        return false
+    }
+    if newParent == node || newParent.isDescendantOf(node) {
+        glog.V(1).Infoln(newParent.label + " is contained in " + node.label)
+        return false
+    }
+    node.reparent(newParent)
+    node.realignHyperedges()
+    node.db.record(Change{Kind: NodeMoved, Node: node.id, Other: newParent.id})
     return true
 }
 
-func (node *Node) ConnectMutualNeighbour(other *Node) bool {
-    if !node.ConnectNeighbour(other) {
+func (node *Node) reparent(newParent *Node) {
+    if node.parent == newParent {
+        return
+    }
+    node.touch()
+    if node.parent != nil {
+        node.parent.touch()
+        node.parent.subnodes, _ = node.parent.subnodes.without(node)
+    } else {
+        node.db.touchGraphs()
+        node.db.graphs, _ = node.db.graphs.without(node)
+    }
+    node.parent = newParent
+    newParent.touch()
+    newParent.subnodes = append(newParent.subnodes, node)
+}
+
+// realignHyperedges puts the hyperedges touching node or its descendants
+// back under the common ancestor of their members.
+func (node *Node) realignHyperedges() {
+    hyperedges := NodeSet(nil)
+    var collect func(*Node)
+    collect = func(current *Node) {
+        if len(current.hypertrail) > 0 {
+            hyperedges.UnionWith(NewNodeSet(current))
+        }
+        hyperedges.UnionWith(current.hyperneighbours)
+        for _, subnode := range current.subnodes {
+            collect(subnode)
+        }
+    }
+    collect(node)
+    for _, hyperedge := range hyperedges {
+        hyperedge.realign()
+    }
+}
+
+// hyperedgeParent is where a hyperedge going through members belongs: their
+// common ancestor, or the parent of the only member there is.
+func hyperedgeParent(members NodeSet) *Node {
+    ancestor := members.commonAncestor()
+    if _, ok := members.ContainsNode(ancestor); ok && ancestor.parent != nil {
+        return ancestor.parent
+    }
+    return ancestor
+}
+
+// realign moves hyperedge under the common ancestor of its members, unless
+// that would put it inside itself.
+func (hyperedge *Node) realign() {
+    ancestor := hyperedgeParent(hyperedge.hypertrail)
+    if ancestor == nil || ancestor == hyperedge || ancestor.isDescendantOf(hyperedge) {
+        return
+    }
+    if ancestor == hyperedge.parent {
+        return
+    }
+    hyperedge.reparent(ancestor)
+    // hyperedges over this one may have to follow
+    for _, outer := range hyperedge.hyperneighbours {
+        outer.realign()
+    }
+}
+
+//------------------- removal
+// Disconnect removes the edge leading from node to other; an undirected edge
+// disappears from both ends. It reports whether other was a neighbour.
+func (node *Node) Disconnect(other *Node) bool {
+    defer node.lock()()
+    if !node.writable() {
         return false
     }
-    if !other.ConnectNeighbour(node) {
-        //TODO disconnect
+    edge := node.edgeTo(other)
+    if edge == nil {
+        glog.V(1).Infoln(other.label + " is not a neighbour of " + node.label)
         return false
     }
+    edge.record(Disconnected)
+    edge.remove()
     return true
 }
 
-func (node *Node) ConnectNewHyperedge(label string, set NodeSet) *Node {
-    parent := set.CommonAncestor()
-    hyperedge := parent.NewSubGraph(label)
-    hyperedge.hypertrail = set
-    for _, hypernode := range set {
-        hypernode.hyperneighbours = append(hypernode.hyperneighbours, hyperedge)
+// DisconnectMutual removes the connection in both directions. It reports
+// whether the two nodes were neighbours of each other.
+func (node *Node) DisconnectMutual(other *Node) bool {
+    defer node.lock()()
+    if !node.writable() {
+        return false
     }
-    return hyperedge
+    mutual := node.edgeTo(other) != nil && other.edgeTo(node) != nil
+    if edge := node.edgeTo(other); edge != nil {
+        edge.record(Disconnected)
+        edge.remove()
+    }
+    if edge := other.edgeTo(node); edge != nil {
+        edge.record(Disconnected)
+        edge.remove()
+    }
+    return mutual
+}
+
+// remove detaches edge from both its ends.
+func (edge *Edge) remove() {
+    edge.from.removeEdge(edge)
+    edge.to.removeEdge(edge)
+    if edge.from.db != nil {
+        edge.from.db.unregisterEdge(edge)
+    }
+}
+
+// removeEdge drops edge from both the outgoing and the incoming side of node.
+func (node *Node) removeEdge(edge *Edge) {
+    node.touch()
+    node.edges, node.neighbours = withoutEdge(node.edges, node.neighbours, edge)
+    node.inEdges, node.inNeighbours = withoutEdge(node.inEdges, node.inNeighbours, edge)
+}
+
+func withoutEdge(edges []*Edge, ends NodeSet, edge *Edge) ([]*Edge, NodeSet) {
+    keptEdges := []*Edge(nil)
+    keptEnds := NodeSet(nil)
+    for i, localEdge := range edges {
+        if localEdge != edge {
+            keptEdges = append(keptEdges, localEdge)
+            keptEnds = append(keptEnds, ends[i])
+        }
+    }
+    return keptEdges, keptEnds
+}
+
+// RemoveHyperedge detaches the hyperedge from all the nodes it goes through
+// and deletes it. It returns false if hyperedge is not a hyperedge.
+func (hyperedge *Node) RemoveHyperedge() bool {
+    defer hyperedge.lock()()
+    if !hyperedge.writable() {
+        return false
+    }
+    if len(hyperedge.hypertrail) == 0 {
+        return false
+    }
+    hyperedge.db.record(Change{Kind: NodeDeleted, Node: hyperedge.id})
+    hyperedge.remove()
+    return true
+}
+
+// Delete removes node and all its subnodes from the graph: they are detached
+// from their parent, from every node connected to them and from all the
+// hyperedges they belong to. Changing a deleted node, deleting it again
+// included, does nothing.
+func (node *Node) Delete() {
+    defer node.lock()()
+    if !node.writable() {
+        return
+    }
+    node.db.record(Change{Kind: NodeDeleted, Node: node.id})
+    node.remove()
+}
+
+func (node *Node) remove() {
+    node.touch()
+    for len(node.subnodes) > 0 {
+        node.subnodes[len(node.subnodes)-1].remove()
+    }
+    if node.parent != nil {
+        node.parent.touch()
+        node.parent.subnodes, _ = node.parent.subnodes.without(node)
+        node.parent = nil
+    }
+    for len(node.inEdges) > 0 {
+        node.inEdges[0].remove()
+    }
+    for len(node.edges) > 0 {
+        node.edges[0].remove()
+    }
+    for _, member := range node.hypertrail {
+        member.touch()
+        member.hyperneighbours, _ = member.hyperneighbours.without(node)
+    }
+    node.hypertrail, node.roles, node.tail, node.head = nil, nil, nil, nil
+    for _, hyperedge := range node.hyperneighbours {
+        hyperedge.dropMember(node)
+        hyperedge.realign()
+    }
+    node.hyperneighbours = nil
+    node.db.unregister(node)
 }
 
 //------------------- exploration
 func (node *Node) UpwardParents() NodeSet {
+    defer node.rlock()()
+    return node.upwardParents()
+}
+
+func (node *Node) upwardParents() NodeSet {
+    node.load()
     parents := NodeSet(nil)
     parent := node.parent
     for parent != nil {
@@ -99,9 +492,26 @@ func (node *Node) UpwardParents() NodeSet {
     return parents
 }
 
+// IsDescendantOf tells whether ancestor contains node, directly or through
+// other subnodes.
+func (node *Node) IsDescendantOf(ancestor *Node) bool {
+    defer node.rlock()()
+    return node.isDescendantOf(ancestor)
+}
+
+func (node *Node) isDescendantOf(ancestor *Node) bool {
+    for parent := node.parent; parent != nil; parent = parent.parent {
+        if parent == ancestor {
+            return true
+        }
+    }
+    return false
+}
+
 func (node *Node) CommonAncestor(other *Node) *Node {
-    nodes1 := node.UpwardParents()
-    nodes2 := other.UpwardParents()
+    defer node.rlock()()
+    nodes1 := node.upwardParents()
+    nodes2 := other.upwardParents()
 
     if len(nodes1) > len(nodes2) {
         nodes2, nodes1 = nodes1, nodes2
@@ -121,15 +531,175 @@ outerLoop:
 }
 
 //------------------- information
+func (node *Node) ID() NodeID {
+    return node.id
+}
+
+func (node *Node) Label() string {
+    return node.label
+}
+
+// Type is inferred from the current structure of node, the first match
+// wins: a node without parent is a Hypergraph, one with a hypertrail a
+// HyperedgeNode, one with subnodes a SubGraph, one with neighbours a
+// GraphNode and anything else a Leaf.
+func (node *Node) Type() NodeType {
+    defer node.rlock()()
+    switch {
+    case node.parent == nil:
+        return Hypergraph
+    case len(node.hypertrail) > 0:
+        return HyperedgeNode
+    case len(node.subnodes) > 0:
+        return SubGraph
+    case len(node.neighbours) > 0:
+        return GraphNode
+    }
+    return Leaf
+}
+
+func (node *Node) Database() *Database {
+    defer node.rlock()()
+    return node.db
+}
+
+// Properties returns the typed properties of node, hyperedges included.
+func (node *Node) Properties() *Properties {
+    node.load()
+    return &node.properties
+}
+
+func (node *Node) Parent() *Node {
+    defer node.rlock()()
+    return node.parent
+}
+
 func (node *Node) Subnodes() NodeSet {
-    return node.subnodes
+    defer node.rlock()()
+    return slices.Clip(node.subnodes)
 }
 
 func (node *Node) Neighbours() NodeSet {
-    return node.neighbours
+    defer node.rlock()()
+    return slices.Clip(node.neighbours)
+}
+
+// OutNeighbours is the same as Neighbours: the nodes node has an edge to,
+// undirected edges included.
+func (node *Node) OutNeighbours() NodeSet {
+    defer node.rlock()()
+    return slices.Clip(node.neighbours)
+}
+
+// InNeighbours returns the nodes having an edge to node, undirected edges
+// included. It is kept up to date on every connection, so it costs nothing
+// to ask.
+func (node *Node) InNeighbours() NodeSet {
+    defer node.rlock()()
+    return slices.Clip(node.inNeighbours)
+}
+
+// Edges returns the edges leading to each of the Neighbours, in the same
+// order.
+func (node *Node) Edges() []*Edge {
+    defer node.rlock()()
+    return slices.Clip(node.edges)
+}
+
+// InEdges returns the edges coming from each of the InNeighbours, in the
+// same order.
+func (node *Node) InEdges() []*Edge {
+    defer node.rlock()()
+    return slices.Clip(node.inEdges)
+}
+
+// EdgeFrom returns the edge leading from other to node, or nil.
+func (node *Node) EdgeFrom(other *Node) *Edge {
+    defer node.rlock()()
+    if i, ok := node.inNeighbours.ContainsNode(other); ok {
+        return node.inEdges[i]
+    }
+    return nil
+}
+
+func (node *Node) OutDegree() int {
+    defer node.rlock()()
+    return len(node.neighbours)
+}
+
+func (node *Node) InDegree() int {
+    defer node.rlock()()
+    return len(node.inNeighbours)
+}
+
+// Degree is the number of edges touching node, each undirected edge counting
+// once and each loop twice.
+func (node *Node) Degree() int {
+    defer node.rlock()()
+    degree := len(node.edges) + len(node.inEdges)
+    for _, edge := range node.edges {
+        if edge.direction == Undirected && edge.from != edge.to {
+            degree--
+        }
+    }
+    return degree
+}
+
+// EdgeTo returns the edge leading from node to other, or nil.
+func (node *Node) EdgeTo(other *Node) *Edge {
+    defer node.rlock()()
+    return node.edgeTo(other)
+}
+
+func (node *Node) edgeTo(other *Node) *Edge {
+    if i, ok := node.neighbours.ContainsNode(other); ok {
+        return node.edges[i]
+    }
+    return nil
+}
+
+// Hypertrail returns the nodes a hyperedge goes through. It is empty for
+// nodes which are not hyperedges.
+func (node *Node) Hypertrail() NodeSet {
+    defer node.rlock()()
+    return slices.Clip(node.hypertrail)
+}
+
+// HyperNeighbours returns the hyperedges going through node.
+func (node *Node) HyperNeighbours() NodeSet {
+    defer node.rlock()()
+    return slices.Clip(node.hyperneighbours)
+}
+
+// hypertrailString displays the members of a hyperedge with their roles, and
+// in turn those of the members which are hyperedges too: "<a:by, e<b, c>>".
+func (hyperedge *Node) hypertrailString() string {
+    display := func(set NodeSet, role func(int, *Node) string) string {
+        str := ""
+        for i, member := range set {
+            str += member.label
+            if len(member.hypertrail) > 0 {
+                str += member.hypertrailString()
+            }
+            if name := role(i, member); name != "" {
+                str += ":" + name
+            }
+            if i != len(set)-1 {
+                str += ", "
+            }
+        }
+        return str
+    }
+    if hyperedge.isDirected() {
+        role := func(_ int, member *Node) string { return hyperedge.role(member) }
+        return "<" + display(hyperedge.tail, role) + " -> " + display(hyperedge.head, role) + ">"
+    }
+    role := func(i int, _ *Node) string { return hyperedge.roles[i] }
+    return "<" + display(hyperedge.hypertrail, role) + ">"
 }
 
 func (parent *Node) String() string {
+    defer parent.rlock()()
     str := parent.label
 
     display := func(set NodeSet, start string, end string) string {
@@ -151,7 +721,7 @@ func (parent *Node) String() string {
         str += " " + display(parent.subnodes, "[", "]")
     }
     if len(parent.hypertrail) > 0 && parent.ShowHypertrail {
-        str += " " + display(parent.hypertrail, "<", ">")
+        str += " " + parent.hypertrailString()
     }
     if len(parent.hyperneighbours) > 0 && parent.ShowHyperNeighbours {
         str += " " + display(parent.hyperneighbours, "{", "}")
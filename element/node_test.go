@@ -165,3 +165,196 @@ func TestNewHyperedge(t *testing.T) {
         t.Error("got", hyperedge)
     }
 }
+
+func TestDisconnect(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := a.NewNeighbour("c")
+
+    if !a.Disconnect(c) {
+        t.Error("expected success")
+    }
+    if a.Disconnect(c) {
+        t.Error("expected failure, c is no longer a neighbour")
+    }
+    if "a (b)" != a.String() {
+        t.Error("actual " + a.String())
+    }
+    if !a.DisconnectMutual(b) {
+        t.Error("expected success")
+    }
+    if len(a.Neighbours()) != 0 || len(b.Neighbours()) != 0 {
+        t.Error("expected no neighbours, got", a, b)
+    }
+    if a.DisconnectMutual(b) {
+        t.Error("expected failure")
+    }
+}
+
+func TestConnectMutualNeighbourRollback(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    b.ConnectNeighbour(a)
+
+    if a.ConnectMutualNeighbour(b) {
+        t.Error("expected failure, b already points at a")
+    }
+    if len(a.Neighbours()) != 0 {
+        t.Error("a should have been disconnected again, got", a)
+    }
+}
+
+func TestDelete(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := b.NewNeighbour("c")
+    d := c.NewSubGraph("d")
+    c.ConnectNeighbour(a)
+    hyperedge := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b, d))
+    db := g.Database()
+
+    c.Delete()
+    if "g [a, b, e]" != g.String() {
+        t.Error("actual " + g.String())
+    }
+    if "b (a) {e}" != b.String() {
+        t.Error("actual " + b.String())
+    }
+    if "e <a, b>" != hyperedge.String() {
+        t.Error("actual " + hyperedge.String())
+    }
+    if nil != db.NodeByLabel("c") || nil != db.NodeByLabel("d") {
+        t.Error("c and d should not be registered anymore")
+    }
+    if 4 != db.Len() {
+        t.Error("expected 4 nodes, got", db.Len())
+    }
+
+    a.Delete()
+    if "b {e}" != b.String() {
+        t.Error("actual " + b.String())
+    }
+    if "e <b>" != hyperedge.String() {
+        t.Error("actual " + hyperedge.String())
+    }
+}
+
+func TestDeleteTwice(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    db := g.Database()
+
+    a.Delete()
+    a.Delete()
+    b.Delete()
+    if nil != a.NewSubGraph("x") || nil != b.NewMutualNeighbour("y") {
+        t.Error("deleted nodes should not get new nodes")
+    }
+    if b.MoveTo(c) || c.MoveTo(b) {
+        t.Error("deleted nodes should not move")
+    }
+    if "g [c]" != g.String() {
+        t.Error("actual " + g.String())
+    }
+    if 2 != db.Len() {
+        t.Error("expected 2 nodes, got", db.Len())
+    }
+}
+
+func TestRemoveHyperedge(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    hyperedge := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b))
+
+    if a.RemoveHyperedge() {
+        t.Error("a is not a hyperedge")
+    }
+    if !hyperedge.RemoveHyperedge() {
+        t.Error("expected success")
+    }
+    if "g [a, b]" != g.String() {
+        t.Error("actual " + g.String())
+    }
+    if "a" != a.String() || "b" != b.String() {
+        t.Error("members should not reference the hyperedge anymore", a, b)
+    }
+    if nil != g.Database().NodeByLabel("e") {
+        t.Error("hyperedge should not be registered anymore")
+    }
+}
+
+func TestNodeType(t *testing.T) {
+    g := element.NewGraph("g")
+    s := g.NewSubGraph("s")
+    a := s.NewSubGraph("a")
+    b := a.NewNeighbour("b")
+    e := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b))
+
+    testData := []struct {
+        node *element.Node
+        expected element.NodeType
+    }{
+        {g, element.Hypergraph},
+        {s, element.SubGraph},
+        {a, element.GraphNode},
+        {b, element.Leaf},
+        {e, element.HyperedgeNode},
+    }
+    for _, data := range testData {
+        if data.expected != data.node.Type() {
+            t.Error("expected", data.node.Label(), "to be a", data.expected, "got", data.node.Type())
+        }
+        if parsed, err := element.ParseNodeType(data.expected.String()); err != nil || parsed != data.expected {
+            t.Error("could not parse", data.expected, parsed, err)
+        }
+    }
+    a.Disconnect(b)
+    if element.Leaf != a.Type() {
+        t.Error("expected a to turn into a leaf, got", a.Type())
+    }
+    if _, err := element.ParseNodeType("vertex"); err == nil {
+        t.Error("expected an error for an unknown type")
+    }
+}
+
+func TestMoveTo(t *testing.T) {
+    g := element.NewGraph("g")
+    s := g.NewSubGraph("s")
+    a := s.NewSubGraph("a")
+    b := s.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    e := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b))
+    if s != e.Parent() {
+        t.Fatal("expected e under s, got", e.Parent())
+    }
+
+    if s.MoveTo(a) || s.MoveTo(s) {
+        t.Error("s can not move under itself")
+    }
+    if !b.MoveTo(c) {
+        t.Error("expected b to move under c")
+    }
+    assertNodeSet(t, "Subnodes of s", element.NewNodeSet(a), s.Subnodes())
+    assertNodeSet(t, "Subnodes of c", element.NewNodeSet(b), c.Subnodes())
+    if c != b.Parent() || !b.IsDescendantOf(g) || b.IsDescendantOf(s) {
+        t.Error("wrong ancestry of b", b.UpwardParents())
+    }
+    if g != e.Parent() {
+        t.Error("expected e to follow the common ancestor of a and b, got", e.Parent())
+    }
+
+    h := g.Database().NewGraph("h")
+    if !h.MoveTo(c) || h.Parent() != c {
+        t.Error("expected the graph h to move under c")
+    }
+    assertNodeSet(t, "Graphs", element.NewNodeSet(g), g.Database().Graphs())
+    if element.NewGraph("x").MoveTo(c) {
+        t.Error("nodes can not move to another database")
+    }
+}
@@ -16,30 +16,95 @@ func (set NodeSet) String() string {
     return str + "]"
 }
 
-func (set NodeSet) Intersect(nodes2 NodeSet) NodeSet {
-    counted := make(map[*Node]bool)
-    for _, node := range set {
-        counted[node] = true
+// All the set operations below treat a NodeSet as an ordered set: results
+// keep the order in which nodes are first seen, starting with the receiver,
+// and never contain duplicates.
+
+// Unique returns set without duplicates.
+func (set NodeSet) Unique() NodeSet {
+    return set.Union()
+}
+
+// Union returns the nodes which are in set or in any of the others.
+func (set NodeSet) Union(others ...NodeSet) NodeSet {
+    seen := NewNodeIndex()
+    union := NodeSet(nil)
+    for _, current := range append([]NodeSet{set}, others...) {
+        for _, node := range current {
+            if seen.Add(node) {
+                union = append(union, node)
+            }
+        }
+    }
+    return union
+}
+
+// Intersect returns the nodes of set which are also in all the others.
+func (set NodeSet) Intersect(others ...NodeSet) NodeSet {
+    counted := make(map[*Node]int)
+    for _, other := range others {
+        for _, node := range other.Unique() {
+            counted[node]++
+        }
     }
     common := NodeSet(nil)
-    for _, node := range nodes2 {
-        if _, ok := counted[node]; ok {
+    for _, node := range set.Unique() {
+        if counted[node] == len(others) {
             common = append(common, node)
         }
     }
     return common
 }
 
-func (set NodeSet) Union(nodes2 NodeSet) NodeSet {
-    return nil
+// Difference returns the nodes of set which are in none of the others.
+func (set NodeSet) Difference(others ...NodeSet) NodeSet {
+    excluded := NewNodeIndex()
+    for _, other := range others {
+        excluded.AddSet(other)
+    }
+    rest := NodeSet(nil)
+    for _, node := range set.Unique() {
+        if !excluded.Contains(node) {
+            rest = append(rest, node)
+        }
+    }
+    return rest
 }
 
-func (set NodeSet) Difference(nodes2 NodeSet) NodeSet {
-    return nil
+// Xor returns the nodes which are in an odd number of the given sets,
+// counting set itself. For two sets this is the symmetric difference.
+func (set NodeSet) Xor(others ...NodeSet) NodeSet {
+    counted := make(map[*Node]int)
+    all := append([]NodeSet{set}, others...)
+    for _, current := range all {
+        for _, node := range current.Unique() {
+            counted[node]++
+        }
+    }
+    odd := NodeSet(nil)
+    for _, node := range set.Union(others...) {
+        if counted[node] % 2 == 1 {
+            odd = append(odd, node)
+        }
+    }
+    return odd
 }
 
-func (set NodeSet) Xor(nodes2 NodeSet) NodeSet {
-    return nil
+//------------------- in place
+func (set *NodeSet) UnionWith(others ...NodeSet) {
+    *set = set.Union(others...)
+}
+
+func (set *NodeSet) IntersectWith(others ...NodeSet) {
+    *set = set.Intersect(others...)
+}
+
+func (set *NodeSet) Subtract(others ...NodeSet) {
+    *set = set.Difference(others...)
+}
+
+func (set *NodeSet) XorWith(others ...NodeSet) {
+    *set = set.Xor(others...)
 }
 
 func (set NodeSet) ContainsNode(node *Node) (int, bool) {
@@ -51,16 +116,43 @@ func (set NodeSet) ContainsNode(node *Node) (int, bool) {
     return -1, false
 }
 
+// without returns a copy of set with every occurrence of node left out, and
+// whether node was found at all.
+func (set NodeSet) without(node *Node) (NodeSet, bool) {
+    if _, ok := set.ContainsNode(node); !ok {
+        return set, false
+    }
+    rest := NodeSet(nil)
+    for _, localNode := range set {
+        if localNode != node {
+            rest = append(rest, localNode)
+        }
+    }
+    return rest, true
+}
+
+// ContainsSubset tells whether every node of otherSet is also in set.
 func (set NodeSet) ContainsSubset(otherSet NodeSet) bool {
-    return false
+    return len(otherSet.Difference(set)) == 0
 }
 
-// TODO use a concurrent version
+// FirstNodeNotIn returns the first node of set which is in none of the given
+// sets.
 func (set NodeSet) FirstNodeNotIn(sets ...NodeSet) *Node {
+    index := NewNodeIndex()
+    for _, other := range sets {
+        index.AddSet(other)
+    }
+    return set.FirstNodeNotInIndex(index)
+}
+
+// FirstNodeNotInIndex is like FirstNodeNotIn, for callers which already keep
+// their exclusions in NodeIndex structures.
+func (set NodeSet) FirstNodeNotInIndex(indexes ...NodeIndex) *Node {
     outerLoop:
     for _, localNode := range set {
-        for _, set := range sets {
-            if _, ok := set.ContainsNode(localNode); ok {
+        for _, index := range indexes {
+            if index.Contains(localNode) {
                 continue outerLoop
             }
         }
@@ -70,6 +162,11 @@ func (set NodeSet) FirstNodeNotIn(sets ...NodeSet) *Node {
 }
 
 func (set NodeSet) CommonAncestor() *Node {
+    defer set.rlock()()
+    return set.commonAncestor()
+}
+
+func (set NodeSet) commonAncestor() *Node {
     if len(set) == 0 {
         return nil
     }
@@ -77,13 +174,13 @@ func (set NodeSet) CommonAncestor() *Node {
         return set[0]
     }
     currentNode := set[0]
-    pruningSubject := currentNode.UpwardParents()
+    pruningSubject := currentNode.upwardParents()
     nodesLeft := set[1:]
     for _, currentNode := range nodesLeft {
         if pruneAtPos, ok := pruningSubject.ContainsNode(currentNode); ok {
             pruningSubject = append(pruningSubject[:pruneAtPos], pruningSubject[pruneAtPos+1:]...)
         } else {
-            currentAncestors := currentNode.UpwardParents()
+            currentAncestors := currentNode.upwardParents()
             pruningSubject = pruningSubject.Intersect(currentAncestors)
         }
     }
@@ -100,3 +197,43 @@ func NewNodeSet(set ...*Node) NodeSet {
     }
     return nodeset
 }
+
+// NodeIndex is a hash set of nodes, for constant time membership checks.
+// Unlike NodeSet it has no order.
+type NodeIndex map[*Node]struct{}
+
+func NewNodeIndex(nodes ...*Node) NodeIndex {
+    index := make(NodeIndex, len(nodes))
+    for _, node := range nodes {
+        index.Add(node)
+    }
+    return index
+}
+
+// Add inserts node and reports whether it was not already there.
+func (index NodeIndex) Add(node *Node) bool {
+    if _, ok := index[node]; ok {
+        return false
+    }
+    index[node] = struct{}{}
+    return true
+}
+
+func (index NodeIndex) AddSet(set NodeSet) {
+    for _, node := range set {
+        index[node] = struct{}{}
+    }
+}
+
+func (index NodeIndex) Remove(node *Node) {
+    delete(index, node)
+}
+
+func (index NodeIndex) Contains(node *Node) bool {
+    _, ok := index[node]
+    return ok
+}
+
+func (set NodeSet) Index() NodeIndex {
+    return NewNodeIndex(set...)
+}
@@ -96,3 +96,71 @@ func TestNodeSetCommonAncestorTree(t *testing.T) {
         t.Error("expected common ancestor", zero, "got", ancestor)
     }
 }
+
+func assertNodeSet(t *testing.T, operation string, expected element.NodeSet, actual element.NodeSet) {
+    if len(expected) != len(actual) {
+        t.Error(operation, "expected", expected, "got", actual)
+        return
+    }
+    for i := range expected {
+        if expected[i] != actual[i] {
+            t.Error(operation, "expected", expected, "got", actual)
+            return
+        }
+    }
+}
+
+func TestNodeSetAlgebra(t *testing.T) {
+    a := element.NewGraph("a")
+    b := element.NewGraph("b")
+    c := element.NewGraph("c")
+    d := element.NewGraph("d")
+    e := element.NewGraph("e")
+    set1 := element.NewNodeSet(c, a, b, a)
+    set2 := element.NewNodeSet(d, b, c)
+    set3 := element.NewNodeSet(e, c)
+
+    assertNodeSet(t, "Unique", element.NewNodeSet(c, a, b), set1.Unique())
+    assertNodeSet(t, "Union", element.NewNodeSet(c, a, b, d), set1.Union(set2))
+    assertNodeSet(t, "Union many", element.NewNodeSet(c, a, b, d, e), set1.Union(set2, set3))
+    assertNodeSet(t, "Intersect", element.NewNodeSet(b, c), set2.Intersect(set1))
+    assertNodeSet(t, "Intersect many", element.NewNodeSet(c), set1.Intersect(set2, set3))
+    assertNodeSet(t, "Difference", element.NewNodeSet(a), set1.Difference(set2))
+    assertNodeSet(t, "Difference many", element.NewNodeSet(d), set2.Difference(set1, set3))
+    assertNodeSet(t, "Xor", element.NewNodeSet(a, d), set1.Xor(set2))
+    assertNodeSet(t, "Xor many", element.NewNodeSet(c, a, d, e), set1.Xor(set2, set3))
+    assertNodeSet(t, "Union empty", nil, element.NodeSet(nil).Union(nil))
+}
+
+func TestNodeSetAlgebraInPlace(t *testing.T) {
+    a := element.NewGraph("a")
+    b := element.NewGraph("b")
+    c := element.NewGraph("c")
+
+    set := element.NewNodeSet(a)
+    set.UnionWith(element.NewNodeSet(b, a), element.NewNodeSet(c))
+    assertNodeSet(t, "UnionWith", element.NewNodeSet(a, b, c), set)
+    set.Subtract(element.NewNodeSet(b))
+    assertNodeSet(t, "Subtract", element.NewNodeSet(a, c), set)
+    set.XorWith(element.NewNodeSet(b, c))
+    assertNodeSet(t, "XorWith", element.NewNodeSet(a, b), set)
+    set.IntersectWith(element.NewNodeSet(b, c))
+    assertNodeSet(t, "IntersectWith", element.NewNodeSet(b), set)
+}
+
+func TestContainsSubset(t *testing.T) {
+    a := element.NewGraph("a")
+    b := element.NewGraph("b")
+    c := element.NewGraph("c")
+    set := element.NewNodeSet(a, b)
+
+    if !set.ContainsSubset(element.NewNodeSet(b, a, b)) {
+        t.Error("expected", set, "to contain [b, a]")
+    }
+    if !set.ContainsSubset(nil) {
+        t.Error("the empty set is a subset of every set")
+    }
+    if set.ContainsSubset(element.NewNodeSet(a, c)) {
+        t.Error("did not expect", set, "to contain [a, c]")
+    }
+}
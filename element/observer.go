@@ -0,0 +1,111 @@
+package element
+
+// An observer is called with every Change made to the database it watches.
+type observer struct {
+    notify func(Change)
+    from Version //changes up to this one happened before it was added
+}
+
+// Observe calls notify with every change made to db from now on, whether it
+// comes from this package, the command line or anywhere else, and returns a
+// function which stops it. The observers are called one change at a time, in
+// version order, once the change is complete and the lock of db released, so
+// they can read and even change db; the changes they make are delivered after
+// the current one. Delivery runs on the goroutine of whichever change finds
+// no delivery in progress, so a change may return before its observers have
+// seen it: use WaitDelivered or Flush to be sure they have.
+func (db *Database) Observe(notify func(Change)) (stop func()) {
+    db.observing.Lock()
+    defer db.observing.Unlock()
+    added := &observer{notify: notify, from: db.Version()}
+    if len(db.observers) == 0 {
+        db.delivered = added.from
+    }
+    db.observers = append(db.observers, added)
+    return func() {
+        db.observing.Lock()
+        defer db.observing.Unlock()
+        for i, registered := range db.observers {
+            if registered == added {
+                db.observers = append(db.observers[:i:i], db.observers[i+1:]...)
+                return
+            }
+        }
+    }
+}
+
+// notify delivers the changes the observers have not seen yet, unless a
+// delivery is in progress already.
+func (db *Database) notify() {
+    if db == nil {
+        return
+    }
+    db.observing.Lock()
+    defer db.observing.Unlock()
+    if !db.delivering {
+        db.deliverAll()
+    }
+}
+
+// deliverAll delivers changes until none is left. It is called and returns
+// with db.observing held.
+func (db *Database) deliverAll() {
+    if len(db.observers) == 0 {
+        return
+    }
+    db.delivering = true
+    defer func() {
+        db.delivering = false
+        db.delivery.Broadcast()
+    }()
+    for {
+        changes := db.Changes(db.delivered, db.Version())
+        if len(changes) == 0 {
+            return
+        }
+        db.deliver(changes, db.observers)
+    }
+}
+
+// deliver calls the observers without holding db.observing, and takes it
+// back even if one of them panics. The panic goes on to the change which
+// triggered the delivery; the change the observer panicked on counts as
+// delivered, the later ones are delivered with the next change.
+func (db *Database) deliver(changes []Change, observers []*observer) {
+    delivered := db.delivered
+    db.observing.Unlock()
+    defer func() {
+        db.observing.Lock()
+        db.delivered = delivered
+        db.delivery.Broadcast()
+    }()
+    for _, change := range changes {
+        delivered = change.Version
+        for _, observer := range observers {
+            if change.Version > observer.from {
+                observer.notify(change)
+            }
+        }
+    }
+}
+
+// WaitDelivered returns once the observers have seen every change up to
+// version, delivering them itself if nobody else is. It must not be called
+// from an observer, which would wait for itself.
+func (db *Database) WaitDelivered(version Version) {
+    db.observing.Lock()
+    defer db.observing.Unlock()
+    for len(db.observers) > 0 && db.delivered < version {
+        if db.delivering {
+            db.delivery.Wait()
+        } else {
+            db.deliverAll()
+        }
+    }
+}
+
+// Flush waits until the observers have seen every change made so far, see
+// WaitDelivered.
+func (db *Database) Flush() {
+    db.WaitDelivered(db.Version())
+}
@@ -0,0 +1,159 @@
+package element_test
+import (
+    "sync"
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+func TestObserve(t *testing.T) {
+    g := element.NewGraph("g")
+    db := g.Database()
+    var seen []element.Change
+    stop := db.Observe(func(change element.Change) {
+        seen = append(seen, change)
+    })
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    e := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b))
+    a.MoveTo(b)
+    a.Properties().Set("n", element.IntValue(1))
+    a.Disconnect(b)
+    e.RemoveHyperedge()
+    a.MoveTo(a) //refused, no change
+    expected := []element.ChangeKind{element.NodeAdded, element.NodeAdded,
+        element.Connected, element.HyperedgeAdded, element.NodeMoved,
+        element.PropertySet, element.Disconnected, element.NodeDeleted}
+    if len(seen) != len(expected) {
+        t.Fatal("expected", expected, "got", seen)
+    }
+    for i, change := range seen {
+        if change.Kind != expected[i] || change.Version != element.Version(i+2) {
+            t.Error("expected", expected[i], "at version", i+2, "got", change)
+        }
+    }
+    if seen[4].Node != a.ID() || seen[4].Other != b.ID() {
+        t.Error("expected a moved under b, got", seen[4])
+    }
+    stop()
+    b.Delete()
+    if len(seen) != len(expected) {
+        t.Error("notified after stop", seen[len(expected):])
+    }
+}
+
+func TestObserverChangesDatabase(t *testing.T) {
+    g := element.NewGraph("g")
+    db := g.Database()
+    var seen []element.Version
+    db.Observe(func(change element.Change) {
+        seen = append(seen, change.Version)
+        // every new subnode of g gets a child, delivered after it
+        if change.Kind == element.NodeAdded && change.Other == g.ID() {
+            db.Node(change.Node).NewSubGraph("child")
+        }
+    })
+    g.NewSubGraph("a")
+    g.NewSubGraph("b")
+    if db.Len() != 5 {
+        t.Error("expected a and b with a child each, got", db.Nodes())
+    }
+    if len(seen) != 4 || seen[0] != 2 || seen[3] != 5 {
+        t.Error("expected versions 2 to 5 in order, got", seen)
+    }
+}
+
+func TestObserveConcurrentWriters(t *testing.T) {
+    g := element.NewGraph("g")
+    db := g.Database()
+    last := db.Version()
+    inOrder := true
+    db.Observe(func(change element.Change) {
+        if change.Version != last+1 {
+            inOrder = false
+        }
+        last = change.Version
+    })
+    var wg sync.WaitGroup
+    for w := 0; w < 4; w++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := 0; i < 50; i++ {
+                g.NewSubGraph("n").Properties().Set("i", element.IntValue(int64(i)))
+            }
+        }()
+    }
+    wg.Wait()
+    // the last writer to finish delivered whatever the others left
+    if !inOrder || last != db.Version() {
+        t.Error("expected every change once and in order, last", last, "of", db.Version())
+    }
+}
+
+func TestPanickingObserver(t *testing.T) {
+    g := element.NewGraph("g")
+    db := g.Database()
+    var seen []element.Version
+    db.Observe(func(change element.Change) {
+        if change.Label == "boom" {
+            panic("observer failed")
+        }
+    })
+    db.Observe(func(change element.Change) {
+        seen = append(seen, change.Version)
+    })
+    func() {
+        defer func() {
+            if recover() == nil {
+                t.Error("expected the panic of the observer")
+            }
+        }()
+        g.NewSubGraph("boom")
+    }()
+    // the database and the observers keep working
+    g.NewSubGraph("a")
+    if len(seen) != 1 || seen[0] != db.Version() || db.Len() != 3 {
+        t.Error("expected only the change after the panic, got", seen)
+    }
+}
+
+func TestWaitDelivered(t *testing.T) {
+    g := element.NewGraph("g")
+    db := g.Database()
+    db.Flush() //nothing to wait for without observers
+    started := make(chan bool)
+    release := make(chan bool)
+    var mu sync.Mutex
+    var seen []element.Version
+    db.Observe(func(change element.Change) {
+        if change.Label == "slow" {
+            started <- true
+            <-release
+        }
+        mu.Lock()
+        defer mu.Unlock()
+        seen = append(seen, change.Version)
+    })
+    go g.NewSubGraph("slow")
+    <-started
+    // the slow delivery is in progress on the other goroutine, this change
+    // returns before it is delivered
+    g.NewSubGraph("a")
+    done := make(chan bool)
+    go func() {
+        db.Flush()
+        done <- true
+    }()
+    select {
+    case <-done:
+        t.Error("flushed while an observer was still busy")
+    default:
+    }
+    close(release)
+    <-done
+    mu.Lock()
+    defer mu.Unlock()
+    if len(seen) != 2 || seen[1] != db.Version() {
+        t.Error("expected both changes delivered, got", seen)
+    }
+}
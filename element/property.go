@@ -0,0 +1,339 @@
+package element
+
+import (
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+type Kind int
+
+const (
+    StringKind Kind = iota
+    IntKind
+    FloatKind
+    BoolKind
+    BytesKind
+    TimeKind
+    ListKind
+)
+
+var kindNames = []string{"string", "int", "float", "bool", "bytes", "time", "list"}
+
+func (kind Kind) String() string {
+    if kind < 0 || int(kind) >= len(kindNames) {
+        return "Kind(" + strconv.Itoa(int(kind)) + ")"
+    }
+    return kindNames[kind]
+}
+
+// ParseKind is the reverse of Kind.String.
+func ParseKind(name string) (Kind, error) {
+    for kind, kindName := range kindNames {
+        if kindName == name {
+            return Kind(kind), nil
+        }
+    }
+    return 0, errors.New("unknown kind " + name)
+}
+
+// Value is a typed property value. The zero Value is the empty string.
+type Value struct {
+    kind Kind
+    str string
+    integer int64
+    float float64
+    boolean bool
+    bytes []byte
+    time time.Time
+    list []Value
+}
+
+func StringValue(value string) Value {
+    return Value{kind: StringKind, str: value}
+}
+
+func IntValue(value int64) Value {
+    return Value{kind: IntKind, integer: value}
+}
+
+func FloatValue(value float64) Value {
+    return Value{kind: FloatKind, float: value}
+}
+
+func BoolValue(value bool) Value {
+    return Value{kind: BoolKind, boolean: value}
+}
+
+func BytesValue(value []byte) Value {
+    return Value{kind: BytesKind, bytes: append([]byte(nil), value...)}
+}
+
+func TimeValue(value time.Time) Value {
+    return Value{kind: TimeKind, time: value}
+}
+
+func ListValue(values ...Value) Value {
+    return Value{kind: ListKind, list: append([]Value(nil), values...)}
+}
+
+// ParseValue reads a value of the given kind from text: bytes are written in
+// hexadecimal and times in RFC 3339. Lists can not be parsed, build them from
+// their parsed items with ListValue.
+func ParseValue(kind Kind, text string) (Value, error) {
+    switch kind {
+    case StringKind:
+        return StringValue(text), nil
+    case IntKind:
+        value, err := strconv.ParseInt(text, 10, 64)
+        return IntValue(value), err
+    case FloatKind:
+        value, err := strconv.ParseFloat(text, 64)
+        return FloatValue(value), err
+    case BoolKind:
+        value, err := strconv.ParseBool(text)
+        return BoolValue(value), err
+    case BytesKind:
+        value, err := hex.DecodeString(text)
+        return BytesValue(value), err
+    case TimeKind:
+        value, err := time.Parse(time.RFC3339, text)
+        return TimeValue(value), err
+    }
+    return Value{}, errors.New("can not parse a " + kind.String())
+}
+
+func (value Value) Kind() Kind {
+    return value.kind
+}
+
+func (value Value) AsString() (string, bool) {
+    return value.str, value.kind == StringKind
+}
+
+func (value Value) AsInt() (int64, bool) {
+    return value.integer, value.kind == IntKind
+}
+
+func (value Value) AsFloat() (float64, bool) {
+    return value.float, value.kind == FloatKind
+}
+
+func (value Value) AsBool() (bool, bool) {
+    return value.boolean, value.kind == BoolKind
+}
+
+// AsBytes returns a copy: values are shared, by snapshots among others, and
+// must not change.
+func (value Value) AsBytes() ([]byte, bool) {
+    return append([]byte(nil), value.bytes...), value.kind == BytesKind
+}
+
+func (value Value) AsTime() (time.Time, bool) {
+    return value.time, value.kind == TimeKind
+}
+
+// AsList returns a copy, like AsBytes.
+func (value Value) AsList() ([]Value, bool) {
+    return append([]Value(nil), value.list...), value.kind == ListKind
+}
+
+// Equal compares kinds and contents.
+func (value Value) Equal(other Value) bool {
+    if value.kind != other.kind {
+        return false
+    }
+    switch value.kind {
+    case BytesKind:
+        return string(value.bytes) == string(other.bytes)
+    case TimeKind:
+        return value.time.Equal(other.time)
+    case ListKind:
+        if len(value.list) != len(other.list) {
+            return false
+        }
+        for i := range value.list {
+            if !value.list[i].Equal(other.list[i]) {
+                return false
+            }
+        }
+        return true
+    }
+    return value.str == other.str && value.integer == other.integer &&
+        value.float == other.float && value.boolean == other.boolean
+}
+
+func (value Value) String() string {
+    switch value.kind {
+    case IntKind:
+        return strconv.FormatInt(value.integer, 10)
+    case FloatKind:
+        return strconv.FormatFloat(value.float, 'g', -1, 64)
+    case BoolKind:
+        return strconv.FormatBool(value.boolean)
+    case BytesKind:
+        return hex.EncodeToString(value.bytes)
+    case TimeKind:
+        return value.time.Format(time.RFC3339)
+    case ListKind:
+        items := make([]string, len(value.list))
+        for i, item := range value.list {
+            items[i] = item.String()
+        }
+        return "[" + strings.Join(items, ", ") + "]"
+    }
+    return value.str
+}
+
+// Properties is the typed key/value store carried by nodes, hyperedges and
+// edges. It has a lock of its own, so properties can be read without locking
+// the whole database; changes lock the database too, as they go into its
+// history. The properties of a Snapshot can not change.
+type Properties struct {
+    mu sync.RWMutex
+    node *Node //the owner, or
+    edge *Edge
+    values map[string]Value
+}
+
+// copyValues returns a copy of the values, for a Snapshot.
+func (properties *Properties) copyValues() map[string]Value {
+    properties.mu.RLock()
+    defer properties.mu.RUnlock()
+    if properties.values == nil {
+        return nil
+    }
+    values := make(map[string]Value, len(properties.values))
+    for key, value := range properties.values {
+        values[key] = value
+    }
+    return values
+}
+
+func (properties *Properties) Get(key string) (Value, bool) {
+    properties.mu.RLock()
+    defer properties.mu.RUnlock()
+    value, ok := properties.values[key]
+    return value, ok
+}
+
+func (properties *Properties) Set(key string, value Value) {
+    defer properties.lockOwner()()
+    if !properties.writable() {
+        return
+    }
+    properties.touch()
+    properties.set(key, value)
+    properties.record(Change{Kind: PropertySet, Key: key, Value: value})
+}
+
+func (properties *Properties) set(key string, value Value) {
+    properties.mu.Lock()
+    defer properties.mu.Unlock()
+    if properties.values == nil {
+        properties.values = make(map[string]Value)
+    }
+    properties.values[key] = value
+}
+
+// Delete removes key and reports whether it was set.
+func (properties *Properties) Delete(key string) bool {
+    defer properties.lockOwner()()
+    if !properties.writable() {
+        return false
+    }
+    properties.touch()
+    if !properties.delete(key) {
+        return false
+    }
+    properties.record(Change{Kind: PropertyDeleted, Key: key})
+    return true
+}
+
+func (properties *Properties) delete(key string) bool {
+    properties.mu.Lock()
+    defer properties.mu.Unlock()
+    _, ok := properties.values[key]
+    delete(properties.values, key)
+    return ok
+}
+
+// lockOwner locks the database of the owner of properties, if any.
+func (properties *Properties) lockOwner() func() {
+    switch {
+    case properties.node != nil:
+        return properties.node.lock()
+    case properties.edge != nil:
+        return properties.edge.lock()
+    }
+    return func() {}
+}
+
+func (properties *Properties) writable() bool {
+    switch {
+    case properties.node != nil:
+        return properties.node.writable()
+    case properties.edge != nil:
+        return properties.edge.from.writable()
+    }
+    return true
+}
+
+// touch saves the properties for the snapshots of the owner before they
+// change.
+func (properties *Properties) touch() {
+    switch {
+    case properties.node != nil:
+        properties.node.touch()
+    case properties.edge != nil:
+        properties.edge.touch()
+    }
+}
+
+func (properties *Properties) record(change Change) {
+    switch {
+    case properties.node != nil:
+        change.Node = properties.node.id
+        properties.node.db.record(change)
+    case properties.edge != nil:
+        change.Edge = properties.edge.id
+        properties.edge.database().record(change)
+    }
+}
+
+// Keys returns all the keys which are set, sorted.
+func (properties *Properties) Keys() []string {
+    properties.mu.RLock()
+    defer properties.mu.RUnlock()
+    return properties.keys()
+}
+
+func (properties *Properties) keys() []string {
+    keys := make([]string, 0, len(properties.values))
+    for key := range properties.values {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+func (properties *Properties) Len() int {
+    properties.mu.RLock()
+    defer properties.mu.RUnlock()
+    return len(properties.values)
+}
+
+func (properties *Properties) String() string {
+    properties.mu.RLock()
+    defer properties.mu.RUnlock()
+    items := []string(nil)
+    for _, key := range properties.keys() {
+        items = append(items, fmt.Sprintf("%s: %s", key, properties.values[key]))
+    }
+    return "{" + strings.Join(items, ", ") + "}"
+}
@@ -0,0 +1,119 @@
+package element_test
+import (
+    "testing"
+    "time"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+func TestValueKinds(t *testing.T) {
+    moment := time.Date(2014, 11, 5, 2, 39, 35, 0, time.UTC)
+    testData := []struct {
+        value element.Value
+        kind element.Kind
+        text string
+    }{
+        {element.StringValue("x"), element.StringKind, "x"},
+        {element.IntValue(-3), element.IntKind, "-3"},
+        {element.FloatValue(0.5), element.FloatKind, "0.5"},
+        {element.BoolValue(true), element.BoolKind, "true"},
+        {element.BytesValue([]byte{0xca, 0xfe}), element.BytesKind, "cafe"},
+        {element.TimeValue(moment), element.TimeKind, "2014-11-05T02:39:35Z"},
+        {element.ListValue(element.IntValue(1), element.StringValue("a")), element.ListKind, "[1, a]"},
+        {element.Value{}, element.StringKind, ""},
+    }
+    for _, data := range testData {
+        if data.kind != data.value.Kind() {
+            t.Error("expected kind", data.kind, "got", data.value.Kind())
+        }
+        if data.text != data.value.String() {
+            t.Error("expected", data.text, "got", data.value.String())
+        }
+        if data.kind == element.ListKind {
+            continue
+        }
+        parsed, err := element.ParseValue(data.kind, data.text)
+        if err != nil || !parsed.Equal(data.value) {
+            t.Error("parsing", data.text, "as", data.kind, "gave", parsed, err)
+        }
+    }
+}
+
+func TestValueAccessors(t *testing.T) {
+    if value, ok := element.IntValue(3).AsInt(); !ok || 3 != value {
+        t.Error("expected 3, got", value)
+    }
+    if _, ok := element.IntValue(3).AsString(); ok {
+        t.Error("an int is not a string")
+    }
+    list, ok := element.ListValue(element.BoolValue(true)).AsList()
+    if !ok || len(list) != 1 || !list[0].Equal(element.BoolValue(true)) {
+        t.Error("expected [true], got", list)
+    }
+    if element.IntValue(1).Equal(element.FloatValue(1)) {
+        t.Error("values of different kinds are never equal")
+    }
+    if _, err := element.ParseValue(element.IntKind, "x"); err == nil {
+        t.Error("expected an error")
+    }
+    if kind, err := element.ParseKind("bytes"); err != nil || element.BytesKind != kind {
+        t.Error("expected bytes, got", kind, err)
+    }
+    if _, err := element.ParseKind("x"); err == nil {
+        t.Error("expected an error")
+    }
+}
+
+func TestValuesDoNotChangeThroughAccessors(t *testing.T) {
+    g := element.NewGraph("g")
+    g.Properties().Set("b", element.BytesValue([]byte("ab")))
+    g.Properties().Set("l", element.ListValue(element.IntValue(1)))
+    snapshot := g.Snapshot()
+
+    value, _ := g.Properties().Get("b")
+    bytes, _ := value.AsBytes()
+    bytes[0] = 'x'
+    value, _ = g.Properties().Get("l")
+    list, _ := value.AsList()
+    list[0] = element.IntValue(2)
+
+    for _, node := range []*element.Node{g, snapshot} {
+        value, _ = node.Properties().Get("b")
+        if bytes, _ := value.AsBytes(); "ab" != string(bytes) {
+            t.Error("expected ab, got", string(bytes))
+        }
+        value, _ = node.Properties().Get("l")
+        if list, _ := value.AsList(); !list[0].Equal(element.IntValue(1)) {
+            t.Error("expected [1], got", list)
+        }
+    }
+}
+
+func TestNodeProperties(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    hyperedge := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b))
+
+    a.Properties().Set("name", element.StringValue("Ann"))
+    a.Properties().Set("age", element.IntValue(33))
+    hyperedge.Properties().Set("weight", element.FloatValue(0.5))
+
+    if value, ok := a.Properties().Get("age"); !ok || !value.Equal(element.IntValue(33)) {
+        t.Error("expected 33, got", value)
+    }
+    if "{age: 33, name: Ann}" != a.Properties().String() {
+        t.Error("actual", a.Properties())
+    }
+    if _, ok := b.Properties().Get("age"); ok || b.Properties().Len() != 0 {
+        t.Error("b has no properties")
+    }
+    if "{weight: 0.5}" != hyperedge.Properties().String() {
+        t.Error("actual", hyperedge.Properties())
+    }
+    if !a.Properties().Delete("age") || a.Properties().Delete("age") {
+        t.Error("expected age to be deleted exactly once")
+    }
+    if keys := a.Properties().Keys(); len(keys) != 1 || "name" != keys[0] {
+        t.Error("expected [name], got", keys)
+    }
+}
@@ -0,0 +1,180 @@
+package element
+
+import (
+    "errors"
+    "sync"
+    "github.com/golang/glog"
+)
+
+// MaxRuleDepth bounds how far rules set each other off: a change made by a
+// rule which reacted to a change made by a rule, and so on, is not passed to
+// the rules anymore past this depth. It stops rules which would otherwise
+// keep the database changing forever.
+const MaxRuleDepth = 16
+
+// Rule makes the database change itself: Then is called with every change
+// for which When holds, and can change the database in turn. Rules see the
+// changes like observers do, once they are complete, see Observe.
+type Rule struct {
+    Name string
+    When func(Change) bool
+    Then func(Change)
+}
+
+type ruleSet struct {
+    sync.Mutex
+    rules []Rule
+    stop func() //stops the observer behind the rules, while there are any
+    depth map[Version]int //how many rules lead to a change, if any
+}
+
+// AddRule attaches rule to db, which evaluates it on every change from now on.
+// It returns false if db already has a rule with the same name.
+func (db *Database) AddRule(rule Rule) bool {
+    db.rules.Lock()
+    defer db.rules.Unlock()
+    for _, existing := range db.rules.rules {
+        if existing.Name == rule.Name {
+            glog.V(1).Infoln("there is a rule", rule.Name, "already")
+            return false
+        }
+    }
+    db.rules.rules = append(db.rules.rules, rule)
+    if db.rules.stop == nil {
+        db.rules.depth = make(map[Version]int)
+        db.rules.stop = db.Observe(db.applyRules)
+    }
+    return true
+}
+
+// RemoveRule detaches the rule called name and reports whether there was one.
+// Once the last rule is gone, db stops passing its changes to the rules.
+func (db *Database) RemoveRule(name string) bool {
+    db.rules.Lock()
+    defer db.rules.Unlock()
+    for i, rule := range db.rules.rules {
+        if rule.Name == name {
+            db.rules.rules = append(db.rules.rules[:i:i], db.rules.rules[i+1:]...)
+            if len(db.rules.rules) == 0 {
+                db.rules.stop()
+                db.rules.stop = nil
+            }
+            return true
+        }
+    }
+    return false
+}
+
+// Rules returns a copy of the rules attached to db, in the order they were
+// added.
+func (db *Database) Rules() []Rule {
+    db.rules.Lock()
+    defer db.rules.Unlock()
+    return append([]Rule(nil), db.rules.rules...)
+}
+
+// applyRules is the observer behind the rules. The changes made while a rule
+// runs count as its consequences, one level deeper than the change it reacted
+// to; concurrent writers may get counted in too, which only makes the limit
+// stricter.
+func (db *Database) applyRules(change Change) {
+    db.rules.Lock()
+    rules := db.rules.rules
+    depth := db.rules.depth[change.Version]
+    delete(db.rules.depth, change.Version)
+    db.rules.Unlock()
+    for _, rule := range rules {
+        if !rule.When(change) {
+            continue
+        }
+        if depth >= MaxRuleDepth {
+            glog.Warningln("rule", rule.Name, "not applied to version", change.Version, "past", MaxRuleDepth, "rules deep")
+            continue
+        }
+        before := db.Version()
+        rule.Then(change)
+        after := db.Version()
+        db.rules.Lock()
+        for version := before + 1; version <= after; version++ {
+            db.rules.depth[version] = depth + 1
+        }
+        db.rules.Unlock()
+    }
+}
+
+// NewNeighbourRule connects every node which gains neighbour to also, with an
+// edge in the same direction, e.g. everyone who knows a also gets to know b.
+func NewNeighbourRule(name string, neighbour *Node, also *Node) Rule {
+    db := neighbour.Database()
+    return Rule{
+        Name: name,
+        When: func(change Change) bool {
+            return change.Kind == Connected && (change.Other == neighbour.id ||
+                change.Direction == Undirected && change.Node == neighbour.id)
+        },
+        Then: func(change Change) {
+            id := change.Node
+            if id == neighbour.id {
+                id = change.Other
+            }
+            if node := db.Node(id); node != nil && node != also {
+                node.Connect(also, change.Direction)
+            }
+        },
+    }
+}
+
+// NewHyperedgeRule creates a node called label next to every hyperedge which
+// comes to go through all of members, when it is created or when it gains
+// the last of them, unless the parent of the hyperedge holds such a node
+// already. Members can not be empty, the rule finds its database through
+// them.
+func NewHyperedgeRule(name string, members NodeSet, label string) (Rule, error) {
+    if len(members) == 0 {
+        return Rule{}, errors.New("the hyperedge rule " + name + " has no members")
+    }
+    db := members[0].Database()
+    covers := func(hyperedge *Node) bool {
+        return hyperedge != nil && len(members.Difference(hyperedge.Members())) == 0
+    }
+    return Rule{
+        Name: name,
+        When: func(change Change) bool {
+            switch change.Kind {
+            case HyperedgeAdded:
+                return covers(db.Node(change.Node))
+            case MemberAdded, TailAdded, HeadAdded:
+                if _, ok := members.ContainsNode(db.Node(change.Other)); !ok {
+                    return false
+                }
+                hyperedge := db.Node(change.Node)
+                // it covered members before unless it just got its only
+                // occurrence of the new member
+                return covers(hyperedge) && countNode(hyperedge.Members(), db.Node(change.Other)) == 1
+            }
+            return false
+        },
+        Then: func(change Change) {
+            hyperedge := db.Node(change.Node)
+            if hyperedge == nil || hyperedge.Parent() == nil {
+                return
+            }
+            for _, subnode := range hyperedge.Parent().Subnodes() {
+                if subnode.Label() == label {
+                    return
+                }
+            }
+            hyperedge.Parent().NewSubGraph(label)
+        },
+    }, nil
+}
+
+func countNode(set NodeSet, node *Node) int {
+    count := 0
+    for _, member := range set {
+        if member == node {
+            count++
+        }
+    }
+    return count
+}
@@ -0,0 +1,106 @@
+package element_test
+import (
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+func TestNeighbourRule(t *testing.T) {
+    g := element.NewGraph("g")
+    db := g.Database()
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    d := g.NewSubGraph("d")
+    if !db.AddRule(element.NewNeighbourRule("knows a", a, b)) {
+        t.Fatal("rule refused")
+    }
+    if db.AddRule(element.NewNeighbourRule("knows a", a, c)) {
+        t.Error("two rules with the same name")
+    }
+    c.ConnectNeighbour(a)
+    if c.EdgeTo(b) == nil {
+        t.Error("expected c to know b as well", c.Neighbours())
+    }
+    d.ConnectMutualNeighbour(a)
+    if d.EdgeTo(b) == nil || d.EdgeTo(b).Direction() != element.Undirected {
+        t.Error("expected an undirected edge from d to b", d.Edges())
+    }
+    rules := db.Rules()
+    rules[0].Name = "renamed"
+    if db.Rules()[0].Name != "knows a" {
+        t.Error("changed a rule through Rules", db.Rules())
+    }
+    a.ConnectNeighbour(d)
+    if a.EdgeTo(b) != nil {
+        t.Error("the rule is about gaining a as a neighbour, not about a")
+    }
+    if !db.RemoveRule("knows a") || len(db.Rules()) != 0 {
+        t.Error("rule not removed", db.Rules())
+    }
+    e := g.NewSubGraph("e")
+    e.ConnectNeighbour(a)
+    if e.EdgeTo(b) != nil {
+        t.Error("removed rule applied")
+    }
+    if db.Observers() != 0 {
+        t.Error("the rules still observe the database without rules")
+    }
+    db.AddRule(element.NewNeighbourRule("knows a again", a, b))
+    f := g.NewSubGraph("f")
+    f.ConnectNeighbour(a)
+    if f.EdgeTo(b) == nil || db.Observers() != 1 {
+        t.Error("rule added again not applied")
+    }
+}
+
+func TestHyperedgeRule(t *testing.T) {
+    g := element.NewGraph("g")
+    db := g.Database()
+    h := g.NewSubGraph("h")
+    a := h.NewSubGraph("a")
+    b := h.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    rule, err := element.NewHyperedgeRule("ab", element.NewNodeSet(a, b), "x")
+    if err != nil || !db.AddRule(rule) {
+        t.Fatal("rule refused", err)
+    }
+    if _, err := element.NewHyperedgeRule("none", nil, "x"); err == nil {
+        t.Error("expected an error for a rule without members")
+    }
+    g.ConnectNewHyperedge("e", element.NewNodeSet(a, c))
+    if db.NodeByLabel("x") != nil {
+        t.Error("created x for a hyperedge over a and c")
+    }
+    f := g.ConnectNewHyperedge("f", element.NewNodeSet(a, b, c))
+    if len(db.NodesByLabel("x")) != 1 || db.NodeByLabel("x").Parent() != g {
+        t.Error("expected x next to f", db.NodesByLabel("x"))
+    }
+    db.NodeByLabel("e").AddMember(b)
+    f.RemoveMember(c)
+    f.AddMember(c)
+    if len(db.NodesByLabel("x")) != 1 {
+        t.Error("g holds x already", db.NodesByLabel("x"))
+    }
+    g.ConnectNewHyperedge("k", element.NewNodeSet(a, b))
+    if len(db.NodesByLabel("x")) != 2 || db.NodesByLabel("x")[1].Parent() != h {
+        t.Error("expected x next to k", db.NodesByLabel("x"))
+    }
+}
+
+func TestRuleDepth(t *testing.T) {
+    g := element.NewGraph("g")
+    db := g.Database()
+    db.AddRule(element.Rule{
+        Name: "grow",
+        When: func(change element.Change) bool {
+            return change.Kind == element.NodeAdded
+        },
+        Then: func(change element.Change) {
+            db.Node(change.Node).NewSubGraph("more")
+        },
+    })
+    g.NewSubGraph("seed")
+    if db.Len() != 2+element.MaxRuleDepth {
+        t.Error("expected", element.MaxRuleDepth, "nodes grown from seed, got", db.Len()-2)
+    }
+}
@@ -0,0 +1,459 @@
+package element
+
+import (
+    "sync"
+    "weak"
+    "github.com/golang/glog"
+)
+
+// Snapshot returns a read-only view of db as it is now. Taking it costs
+// next to nothing: the snapshot shares the nodes of db, and a writer saves
+// the links and properties of a node the first time it changes it after the
+// snapshot, so the snapshot still finds them as they were. A node of the
+// snapshot is copied from db, or from what was saved of it, when it is first
+// read. A traversal over a snapshot sees one consistent state from start to
+// end, and its readers only wait for writers while a node gets copied. The
+// iterators and NodeSet operations work on a snapshot like on any other
+// database; every change is refused.
+func (db *Database) Snapshot() *Database {
+    if db.readOnly {
+        return db
+    }
+    defer db.lock()()
+    saved := &frozen{
+        lastID: db.lastID,
+        lastEdgeID: db.lastEdgeID,
+        nodes: make(map[NodeID]*frozenNode),
+        edges: make(map[EdgeID]*frozenEdge),
+        labels: make(map[string]NodeSet),
+    }
+    if previous := db.frozen.Value(); previous != nil {
+        previous.next = saved
+    }
+    db.frozen = weak.Make(saved)
+    snapshot := NewDatabase()
+    snapshot.readOnly = true
+    snapshot.lastID, snapshot.lastEdgeID = db.lastID, db.lastEdgeID
+    snapshot.history = db.history[:len(db.history):len(db.history)]
+    snapshot.view = &view{
+        snapshot: snapshot,
+        source: db,
+        frozen: saved,
+        nodes: make(map[*Node]*Node),
+        edges: make(map[*Edge]*Edge),
+    }
+    return snapshot
+}
+
+// Snapshot returns the copy of node in a Snapshot of its database, or nil if
+// node has been deleted.
+func (node *Node) Snapshot() *Node {
+    db := node.Database()
+    if db == nil {
+        return nil
+    }
+    return db.Snapshot().Node(node.id)
+}
+
+// IsReadOnly tells whether db is a snapshot.
+func (db *Database) IsReadOnly() bool {
+    return db.readOnly
+}
+
+// writable refuses changes to a snapshot.
+func (db *Database) writable() bool {
+    if db.readOnly {
+        glog.V(1).Infoln("a snapshot can not be changed")
+        return false
+    }
+    return true
+}
+
+// writable refuses changes to deleted nodes, which have no database anymore:
+// another goroutine may have deleted the node, or one of its parents, in
+// the meantime.
+func (node *Node) writable() bool {
+    if node.db == nil {
+        glog.V(1).Infoln(node.label + " has been deleted")
+        return false
+    }
+    return node.db.writable()
+}
+
+//------------------- saving
+// frozen keeps what changed in a database since a snapshot was taken, as it
+// was then: the nodes, edges, label lists and top level graphs which writers
+// went on to change. Whatever is not in there is still the same. The frozen
+// state of the next snapshot follows in next, as what changed only after it
+// had not changed before it either.
+type frozen struct {
+    lastID NodeID //nodes and edges created later are not saved
+    lastEdgeID EdgeID
+    nodes map[NodeID]*frozenNode
+    edges map[EdgeID]*frozenEdge
+    labels map[string]NodeSet
+    graphs NodeSet
+    graphsSaved bool
+    next *frozen
+}
+
+type frozenNode struct {
+    node *Node
+    links
+    properties map[string]Value
+}
+
+type frozenEdge struct {
+    edge *Edge
+    label string
+    weight float64
+    properties map[string]Value
+}
+
+// saved returns where the writers of db save what they change, or nil if no
+// snapshot shares anything with db anymore. The snapshots hold on to what
+// was saved for them, db only to the latest of it, weakly, so nothing gets
+// saved for the snapshots which are gone.
+func (db *Database) saved() *frozen {
+    if db == nil {
+        return nil
+    }
+    return db.frozen.Value()
+}
+
+// touch saves node before a writer changes it, unless it did already since
+// the latest snapshot.
+func (node *Node) touch() {
+    saved := node.db.saved()
+    if saved == nil || node.id > saved.lastID {
+        return
+    }
+    if _, ok := saved.nodes[node.id]; !ok {
+        saved.nodes[node.id] = node.freeze()
+    }
+}
+
+func (node *Node) freeze() *frozenNode {
+    return &frozenNode{node: node, links: node.links, properties: node.properties.copyValues()}
+}
+
+func (edge *Edge) touch() {
+    saved := edge.from.db.saved()
+    if saved == nil || edge.id > saved.lastEdgeID {
+        return
+    }
+    if _, ok := saved.edges[edge.id]; !ok {
+        saved.edges[edge.id] = edge.freeze()
+    }
+}
+
+func (edge *Edge) freeze() *frozenEdge {
+    return &frozenEdge{
+        edge: edge,
+        label: edge.label,
+        weight: edge.weight,
+        properties: edge.properties.copyValues(),
+    }
+}
+
+func (db *Database) touchLabel(label string) {
+    if saved := db.saved(); saved != nil {
+        if _, ok := saved.labels[label]; !ok {
+            saved.labels[label] = db.labels[label]
+        }
+    }
+}
+
+func (db *Database) touchGraphs() {
+    if saved := db.saved(); saved != nil && !saved.graphsSaved {
+        saved.graphs, saved.graphsSaved = db.graphs, true
+    }
+}
+
+// touchAll saves everything, for the repairs, which change the structure
+// without saying what they touch.
+func (db *Database) touchAll() {
+    saved := db.saved()
+    if saved == nil {
+        return
+    }
+    for id, node := range db.nodes {
+        if _, ok := saved.nodes[id]; !ok && id <= saved.lastID {
+            saved.nodes[id] = node.freeze()
+        }
+    }
+    for id, edge := range db.edges {
+        if _, ok := saved.edges[id]; !ok && id <= saved.lastEdgeID {
+            saved.edges[id] = edge.freeze()
+        }
+    }
+    for label := range db.labels {
+        db.touchLabel(label)
+    }
+    db.touchGraphs()
+}
+
+//------------------- reading
+// view is where a snapshot reads its nodes from: the database it was taken
+// of, except for what was saved since.
+type view struct {
+    snapshot *Database
+    source *Database
+    frozen *frozen
+    mu sync.Mutex //guards the maps and the loading of nodes
+    nodes map[*Node]*Node //the copy of each node of source
+    edges map[*Edge]*Edge
+    all sync.Once
+}
+
+// load copies the links and properties node had when the snapshot was
+// taken, the first time it is read. Its parents and its members, which are
+// read along with it without locking them, get loaded too.
+func (node *Node) load() {
+    if node.unloaded.Load() {
+        node.db.view.load(node)
+    }
+}
+
+func (view *view) load(node *Node) {
+    view.mu.Lock()
+    defer view.mu.Unlock()
+    defer view.source.rlock()()
+    view.loadLocked(node)
+}
+
+func (view *view) loadLocked(node *Node) {
+    loaded := NodeSet(nil)
+    seen := NewNodeIndex()
+    for pending := NewNodeSet(node); len(pending) > 0; {
+        current := pending[len(pending)-1]
+        pending = pending[:len(pending)-1]
+        if !current.unloaded.Load() || !seen.Add(current) {
+            continue
+        }
+        saved := view.frozenNode(current.id)
+        current.links = links{
+            parent: view.node(saved.parent),
+            subnodes: view.set(saved.subnodes),
+            neighbours: view.set(saved.neighbours),
+            edges: view.edgeList(saved.edges),
+            inNeighbours: view.set(saved.inNeighbours),
+            inEdges: view.edgeList(saved.inEdges),
+            hypertrail: view.set(saved.hypertrail),
+            hyperneighbours: view.set(saved.hyperneighbours),
+            roles: saved.roles,
+            ordered: saved.ordered,
+            tail: view.set(saved.tail),
+            head: view.set(saved.head),
+        }
+        current.properties.values = saved.properties
+        loaded = append(loaded, current)
+        if current.parent != nil {
+            pending = append(pending, current.parent)
+        }
+        pending = append(pending, current.hypertrail...)
+    }
+    for _, node := range loaded {
+        node.unloaded.Store(false)
+    }
+}
+
+// frozenNode returns the node with the given ID as it was when the snapshot
+// was taken, or nil if there was none.
+func (view *view) frozenNode(id NodeID) *frozenNode {
+    if id > view.snapshot.lastID {
+        return nil
+    }
+    for saved := view.frozen; saved != nil; saved = saved.next {
+        if node, ok := saved.nodes[id]; ok {
+            return node
+        }
+    }
+    if node := view.source.nodes[id]; node != nil {
+        return node.freeze()
+    }
+    return nil
+}
+
+func (view *view) frozenEdge(id EdgeID) *frozenEdge {
+    if id > view.snapshot.lastEdgeID {
+        return nil
+    }
+    for saved := view.frozen; saved != nil; saved = saved.next {
+        if edge, ok := saved.edges[id]; ok {
+            return edge
+        }
+    }
+    if edge := view.source.edges[id]; edge != nil {
+        return edge.freeze()
+    }
+    return nil
+}
+
+// node returns the copy of node, which is only loaded once read.
+func (view *view) node(node *Node) *Node {
+    if node == nil {
+        return nil
+    }
+    if copied, ok := view.nodes[node]; ok {
+        return copied
+    }
+    copied := newNode(node.label)
+    copied.id, copied.db, copied.mu = node.id, view.snapshot, &view.snapshot.mu
+    copied.properties.node = copied
+    copied.ShowNeighbours = node.ShowNeighbours
+    copied.ShowHypertrail = node.ShowHypertrail
+    copied.ShowSubnodes = node.ShowSubnodes
+    copied.ShowHyperNeighbours = node.ShowHyperNeighbours
+    copied.unloaded.Store(true)
+    view.nodes[node] = copied
+    return copied
+}
+
+func (view *view) set(set NodeSet) NodeSet {
+    if set == nil {
+        return nil
+    }
+    copied := make(NodeSet, len(set))
+    for i, node := range set {
+        copied[i] = view.node(node)
+    }
+    return copied
+}
+
+func (view *view) edge(edge *Edge) *Edge {
+    if copied, ok := view.edges[edge]; ok {
+        return copied
+    }
+    saved := view.frozenEdge(edge.id)
+    copied := &Edge{
+        id: edge.id,
+        mu: &view.snapshot.mu,
+        from: view.node(edge.from),
+        to: view.node(edge.to),
+        direction: edge.direction,
+        label: saved.label,
+        weight: saved.weight,
+    }
+    copied.properties.edge = copied
+    copied.properties.values = saved.properties
+    view.edges[edge] = copied
+    return copied
+}
+
+func (view *view) edgeList(list []*Edge) []*Edge {
+    if list == nil {
+        return nil
+    }
+    copied := make([]*Edge, len(list))
+    for i, edge := range list {
+        copied[i] = view.edge(edge)
+    }
+    return copied
+}
+
+func (view *view) lookup(id NodeID) *Node {
+    view.mu.Lock()
+    defer view.mu.Unlock()
+    defer view.source.rlock()()
+    if saved := view.frozenNode(id); saved != nil {
+        return view.node(saved.node)
+    }
+    return nil
+}
+
+func (view *view) lookupEdge(id EdgeID) *Edge {
+    view.mu.Lock()
+    defer view.mu.Unlock()
+    defer view.source.rlock()()
+    if saved := view.frozenEdge(id); saved != nil {
+        return view.edge(saved.edge)
+    }
+    return nil
+}
+
+func (view *view) labelled(label string) NodeSet {
+    view.mu.Lock()
+    defer view.mu.Unlock()
+    defer view.source.rlock()()
+    return view.set(view.frozenLabel(label))
+}
+
+func (view *view) frozenLabel(label string) NodeSet {
+    for saved := view.frozen; saved != nil; saved = saved.next {
+        if set, ok := saved.labels[label]; ok {
+            return set
+        }
+    }
+    return view.source.labels[label]
+}
+
+func (view *view) topLevel() NodeSet {
+    view.mu.Lock()
+    defer view.mu.Unlock()
+    defer view.source.rlock()()
+    return view.set(view.frozenGraphs())
+}
+
+func (view *view) frozenGraphs() NodeSet {
+    for saved := view.frozen; saved != nil; saved = saved.next {
+        if saved.graphsSaved {
+            return saved.graphs
+        }
+    }
+    return view.source.graphs
+}
+
+// loadAll copies the whole snapshot into its own maps, once, for the methods
+// which read every node.
+func (db *Database) loadAll() {
+    if db.view != nil {
+        db.view.all.Do(db.view.loadAll)
+    }
+}
+
+func (view *view) loadAll() {
+    view.mu.Lock()
+    defer view.mu.Unlock()
+    defer view.source.rlock()()
+    snapshot := view.snapshot
+    labels := make(map[string]bool)
+    for id := range view.source.nodes {
+        view.loadID(id)
+    }
+    for label := range view.source.labels {
+        labels[label] = true
+    }
+    for saved := view.frozen; saved != nil; saved = saved.next {
+        for id := range saved.nodes {
+            view.loadID(id)
+        }
+        for id := range saved.edges {
+            if edge := view.frozenEdge(id); edge != nil {
+                snapshot.edges[id] = view.edge(edge.edge)
+            }
+        }
+        for label := range saved.labels {
+            labels[label] = true
+        }
+    }
+    for id, edge := range view.source.edges {
+        if id <= snapshot.lastEdgeID {
+            snapshot.edges[id] = view.edge(edge)
+        }
+    }
+    for label := range labels {
+        if set := view.frozenLabel(label); len(set) > 0 {
+            snapshot.labels[label] = view.set(set)
+        }
+    }
+    snapshot.graphs = view.set(view.frozenGraphs())
+}
+
+func (view *view) loadID(id NodeID) {
+    if saved := view.frozenNode(id); saved != nil {
+        node := view.node(saved.node)
+        view.loadLocked(node)
+        view.snapshot.nodes[id] = node
+    }
+}
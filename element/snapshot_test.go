@@ -0,0 +1,146 @@
+package element_test
+import (
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+func TestSnapshot(t *testing.T) {
+    db := element.NewDatabase()
+    g := db.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := a.NewNeighbour("c")
+    e := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b))
+    e.SetRole(a, "subject")
+    a.Properties().Set("weight", element.IntValue(3))
+
+    snapshot := db.Snapshot()
+    if !snapshot.IsReadOnly() || db.IsReadOnly() {
+        t.Fatal("only the snapshot is read-only")
+    }
+    if db.Len() != snapshot.Len() || len(db.Edges()) != len(snapshot.Edges()) {
+        t.Fatal("snapshot differs", snapshot.Nodes())
+    }
+    sa := snapshot.Node(a.ID())
+    if sa == a || sa.String() != a.String() {
+        t.Error("expected a copy of", a, "got", sa)
+    }
+    se := snapshot.Node(e.ID())
+    if se.Role(sa) != "subject" || se.Members()[1] != snapshot.Node(b.ID()) {
+        t.Error("hyperedge not copied", se)
+    }
+    if value, _ := sa.Properties().Get("weight"); !value.Equal(element.IntValue(3)) {
+        t.Error("properties not copied", sa.Properties())
+    }
+
+    // the original goes on changing, the snapshot stays as it was
+    c.Delete()
+    a.Disconnect(b)
+    a.Properties().Set("weight", element.IntValue(4))
+    g.NewSubGraph("d")
+    if snapshot.Len() != 5 || snapshot.Node(c.ID()) == nil || len(sa.Neighbours()) != 2 {
+        t.Error("snapshot changed with the database", snapshot.Nodes())
+    }
+    if value, _ := sa.Properties().Get("weight"); !value.Equal(element.IntValue(3)) {
+        t.Error("snapshot properties changed", sa.Properties())
+    }
+    if len(snapshot.Validate()) > 0 {
+        t.Error("inconsistent snapshot", snapshot.Validate())
+    }
+
+    // and refuses every change
+    if sa.NewSubGraph("x") != nil || snapshot.NewGraph("x") != nil {
+        t.Error("created a node in a snapshot")
+    }
+    if sa.Disconnect(snapshot.Node(b.ID())) || sa.MoveTo(snapshot.Node(g.ID())) {
+        t.Error("changed a snapshot")
+    }
+    if se.AddMember(snapshot.Node(c.ID())) || se.RemoveHyperedge() {
+        t.Error("changed a hyperedge of a snapshot")
+    }
+    snapshot.Node(c.ID()).Delete()
+    sa.Properties().Set("weight", element.IntValue(5))
+    if snapshot.Len() != 5 || sa.Properties().Len() != 1 {
+        t.Error("changed a snapshot")
+    }
+    if value, _ := sa.Properties().Get("weight"); !value.Equal(element.IntValue(3)) {
+        t.Error("changed snapshot properties", sa.Properties())
+    }
+    if snapshot.Snapshot() != snapshot {
+        t.Error("a snapshot of a snapshot is the snapshot itself")
+    }
+}
+
+func TestNodeSnapshot(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    sb := b.Snapshot()
+    if sb == b || sb.ID() != b.ID() || sb.Parent().ID() != g.ID() {
+        t.Error("wrong snapshot", sb)
+    }
+    if !sb.Database().IsReadOnly() || sb.Database().Node(a.ID()) == nil {
+        t.Error("expected the whole database in the snapshot")
+    }
+    b.Delete()
+    if b.Snapshot() != nil {
+        t.Error("snapshot of a deleted node")
+    }
+}
+
+func TestSnapshotCopyOnWrite(t *testing.T) {
+    db := element.NewDatabase()
+    g := db.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := g.NewSubGraph("c")
+    e := g.ConnectNewHyperedge("e", element.NewNodeSet(a, b))
+    a.EdgeTo(b).SetLabel("knows")
+    b.Properties().Set("age", element.IntValue(7))
+
+    // nothing is read from the first snapshot before the changes
+    first := db.Snapshot()
+    b.Delete()
+    c.MoveTo(a)
+    e.AddMember(c)
+    a.Properties().Set("weight", element.IntValue(3))
+    second := db.Snapshot()
+    g.NewSubGraph("d")
+    g.NewSubGraph("a")
+    c.Delete()
+
+    sa := first.Node(a.ID())
+    sb := first.Node(b.ID())
+    if sb == nil || len(sa.Neighbours()) != 1 || sa.Neighbours()[0] != sb {
+        t.Fatal("lost the neighbour of a", sa, sb)
+    }
+    if sa.EdgeTo(sb).Label() != "knows" || sa.Properties().Len() != 0 {
+        t.Error("edge or properties changed", sa.EdgeTo(sb), sa.Properties())
+    }
+    if value, _ := sb.Properties().Get("age"); !value.Equal(element.IntValue(7)) {
+        t.Error("lost the properties of a deleted node", sb.Properties())
+    }
+    if sc := first.Node(c.ID()); sc.Parent() != first.Node(g.ID()) || len(sc.HyperNeighbours()) != 0 {
+        t.Error("c changed", sc)
+    }
+    if members := first.Node(e.ID()).Members(); len(members) != 2 || members[1] != sb {
+        t.Error("members changed", members)
+    }
+    if first.Len() != 5 || len(first.NodesByLabel("a")) != 1 || first.NodeByLabel("d") != nil {
+        t.Error("nodes changed", first.Nodes())
+    }
+    if len(first.Edges()) != 1 || len(first.Validate()) > 0 {
+        t.Error("inconsistent snapshot", first.Edges(), first.Validate())
+    }
+
+    // the second one sees the changes made before it, not those after
+    if second.Node(b.ID()) != nil || second.Node(c.ID()).Parent() != second.Node(a.ID()) {
+        t.Error("second snapshot misses changes", second.Nodes())
+    }
+    if second.Len() != 4 || len(second.Graphs()) != 1 || second.NodeByLabel("d") != nil {
+        t.Error("second snapshot sees later changes", second.Nodes())
+    }
+    if len(second.Validate()) > 0 {
+        t.Error("inconsistent snapshot", second.Validate())
+    }
+}
@@ -0,0 +1,405 @@
+package element
+
+import (
+    "fmt"
+)
+
+// Violation is a broken invariant of the structure found by Validate.
+type Violation struct {
+    Node *Node
+    Description string
+    repair func()
+    db *Database
+}
+
+// Repairable tells whether Repair knows how to fix the violation.
+func (violation Violation) Repairable() bool {
+    return violation.repair != nil
+}
+
+// Repair fixes the violation and returns true, or returns false if it can not
+// be fixed automatically. A repair never assumes the rest of the structure is
+// sound, so repairing the violations of one Validate run in any order is
+// safe; running Validate again may find what the repairs uncovered.
+func (violation Violation) Repair() bool {
+    if violation.repair == nil {
+        return false
+    }
+    defer violation.Node.lock()()
+    violation.db.touchAll()
+    violation.repair()
+    return true
+}
+
+func (violation Violation) String() string {
+    return violation.Node.label + ": " + violation.Description
+}
+
+// Validate checks every invariant the database relies on and returns all the
+// violations, in node ID order. It works like fsck: it reads everything and
+// fixes nothing, see Repair.
+//
+// The edges registered in db are trusted over the edge lists of the nodes,
+// and the subnodes of a node over the parent of the subnode, unless that
+// parent holds the subnode as well.
+func (db *Database) Validate() []Violation {
+    defer db.rlock()()
+    return db.validate()
+}
+
+func (db *Database) validate() []Violation {
+    checker := &checker{db: db, unrooted: NewNodeIndex()}
+    nodes := db.sortedNodes()
+    for _, node := range nodes {
+        checker.findRoot(node)
+    }
+    for _, node := range nodes {
+        checker.checkRegistration(node)
+        checker.checkContainment(node)
+        checker.checkEdges(node)
+        checker.checkHyperedge(node)
+        checker.checkHyperneighbours(node)
+    }
+    checker.checkRegisteredEdges()
+    return checker.violations
+}
+
+// Repair validates db and repairs what it can, until nothing repairable is
+// left. It returns the violations which are left.
+func (db *Database) Repair() []Violation {
+    defer db.lock()()
+    const maxPasses = 10
+    violations := db.validate()
+    db.touchAll()
+    for pass := 0; pass < maxPasses; pass++ {
+        repaired := false
+        for _, violation := range violations {
+            if violation.repair != nil {
+                violation.repair()
+                repaired = true
+            }
+        }
+        if !repaired {
+            break
+        }
+        violations = db.validate()
+    }
+    return violations
+}
+
+type checker struct {
+    db *Database
+    violations []Violation
+    unrooted NodeIndex //nodes whose parents lead into a cycle
+}
+
+// findRoot walks up from node and remembers it as unrooted if its parents
+// never end; nothing which walks up the parents is safe for those nodes.
+func (checker *checker) findRoot(node *Node) {
+    visited := NewNodeIndex()
+    for ancestor := node; ancestor != nil; ancestor = ancestor.parent {
+        if !visited.Add(ancestor) {
+            checker.unrooted.Add(node)
+            return
+        }
+    }
+}
+
+func (checker *checker) rooted(nodes ...*Node) bool {
+    for _, node := range nodes {
+        if checker.unrooted.Contains(node) {
+            return false
+        }
+    }
+    return true
+}
+
+// report drops the repair for snapshots, which can not change.
+func (checker *checker) report(node *Node, repair func(), format string, args ...interface{}) {
+    if checker.db.readOnly {
+        repair = nil
+    }
+    checker.violations = append(checker.violations, Violation{
+        Node: node,
+        Description: fmt.Sprintf(format, args...),
+        repair: repair,
+        db: checker.db,
+    })
+}
+
+func (checker *checker) alive(node *Node) bool {
+    return node != nil && node.db == checker.db && checker.db.nodes[node.id] == node
+}
+
+func (checker *checker) checkRegistration(node *Node) {
+    db := checker.db
+    if node.db != db {
+        checker.report(node, func() {
+            node.db = db
+        }, "does not point at its database")
+    }
+    if _, ok := db.labels[node.label].ContainsNode(node); !ok {
+        checker.report(node, func() {
+            if _, ok := db.labels[node.label].ContainsNode(node); !ok {
+                db.labels[node.label] = append(db.labels[node.label], node)
+            }
+        }, "is missing from the label index")
+    }
+}
+
+func (checker *checker) checkContainment(node *Node) {
+    db := checker.db
+    for _, subnode := range node.subnodes.Unique() {
+        switch {
+        case !checker.alive(subnode):
+            checker.report(node, func() {
+                node.subnodes, _ = node.subnodes.without(subnode)
+            }, "holds the deleted node %s", subnode.label)
+        case subnode.parent != node && checker.holds(subnode.parent, subnode):
+            checker.report(node, func() {
+                node.subnodes, _ = node.subnodes.without(subnode)
+            }, "holds %s, which belongs to %s", subnode.label, subnode.parent.label)
+        case subnode.parent != node:
+            checker.report(node, func() {
+                subnode.parent = node
+                db.graphs, _ = db.graphs.without(subnode)
+            }, "holds %s, which does not have it as parent", subnode.label)
+        }
+    }
+    if len(node.subnodes.Unique()) != len(node.subnodes) {
+        checker.report(node, func() {
+            node.subnodes = node.subnodes.Unique()
+        }, "holds some subnodes more than once")
+    }
+    if node.parent == nil {
+        if _, ok := db.graphs.ContainsNode(node); !ok {
+            checker.report(node, func() {
+                if _, ok := db.graphs.ContainsNode(node); !ok && node.parent == nil {
+                    db.graphs = append(db.graphs, node)
+                }
+            }, "has no parent but is not a top level graph")
+        }
+        return
+    }
+    if _, ok := db.graphs.ContainsNode(node); ok {
+        checker.report(node, func() {
+            db.graphs, _ = db.graphs.without(node)
+        }, "is a top level graph but has the parent %s", node.parent.label)
+    }
+    if !checker.alive(node.parent) {
+        // the subnode of a deleted node is lost: hand it to the top level
+        checker.report(node, func() {
+            node.parent = nil
+            if _, ok := db.graphs.ContainsNode(node); !ok {
+                db.graphs = append(db.graphs, node)
+            }
+        }, "has the deleted node %s as parent", node.parent.label)
+    } else if _, ok := node.parent.subnodes.ContainsNode(node); !ok {
+        checker.report(node, func() {
+            if _, ok := node.parent.subnodes.ContainsNode(node); !ok {
+                node.parent.subnodes = append(node.parent.subnodes, node)
+            }
+        }, "is missing from the subnodes of its parent %s", node.parent.label)
+    }
+    if !checker.unrooted.Contains(node) {
+        return
+    }
+    visited := NewNodeIndex()
+    for ancestor := node.parent; visited.Add(ancestor); ancestor = ancestor.parent {
+        if ancestor == node {
+            checker.report(node, nil, "is contained in itself")
+            return
+        }
+    }
+}
+
+func (checker *checker) holds(parent *Node, subnode *Node) bool {
+    if !checker.alive(parent) {
+        return false
+    }
+    _, ok := parent.subnodes.ContainsNode(subnode)
+    return ok
+}
+
+// checkEdges checks the edge lists of node, the edges themselves are checked
+// by checkRegisteredEdges.
+func (checker *checker) checkEdges(node *Node) {
+    checker.checkEdgeList(node, "outgoing", &node.edges, &node.neighbours,
+        func(edge *Edge) bool {
+            return edge.from == node || (edge.direction == Undirected && edge.to == node)
+        })
+    checker.checkEdgeList(node, "incoming", &node.inEdges, &node.inNeighbours,
+        func(edge *Edge) bool {
+            return edge.to == node || (edge.direction == Undirected && edge.from == node)
+        })
+}
+
+func (checker *checker) checkEdgeList(node *Node, kind string, edges *[]*Edge, ends *NodeSet, belongs func(*Edge) bool) {
+    rebuild := func() {
+        kept := []*Edge(nil)
+        keptEnds := NodeSet(nil)
+        for _, edge := range *edges {
+            if checker.db.edges[edge.id] == edge && belongs(edge) {
+                kept = append(kept, edge)
+                keptEnds = append(keptEnds, edge.Other(node))
+            }
+        }
+        *edges, *ends = kept, keptEnds
+    }
+    if len(*edges) != len(*ends) {
+        checker.report(node, rebuild, "has %d %s edges for %d neighbours", len(*edges), kind, len(*ends))
+        return
+    }
+    for i, edge := range *edges {
+        switch {
+        case checker.db.edges[edge.id] != edge:
+            checker.report(node, rebuild, "has the unregistered %s edge %s", kind, edge.string())
+        case !belongs(edge):
+            checker.report(node, rebuild, "has the %s edge %s, which does not touch it that way", kind, edge.string())
+        case (*ends)[i] != edge.Other(node):
+            checker.report(node, rebuild, "lists %s as %s neighbour in place of %s", (*ends)[i].label, kind, edge.Other(node).label)
+        }
+    }
+}
+
+// checkRegisteredEdges makes sure that both ends know about every edge.
+func (checker *checker) checkRegisteredEdges() {
+    for _, edge := range checker.db.sortedEdges() {
+        edge := edge
+        if !checker.alive(edge.from) || !checker.alive(edge.to) {
+            checker.report(edge.from, func() {
+                edge.from.removeEdge(edge)
+                edge.to.removeEdge(edge)
+                checker.db.unregisterEdge(edge)
+            }, "has the edge %s to or from a deleted node", edge.string())
+            continue
+        }
+        checker.checkEdgeEnd(edge, edge.from, &edge.from.edges, &edge.from.neighbours, "outgoing")
+        checker.checkEdgeEnd(edge, edge.to, &edge.to.inEdges, &edge.to.inNeighbours, "incoming")
+        if edge.direction == Undirected && edge.from != edge.to {
+            checker.checkEdgeEnd(edge, edge.to, &edge.to.edges, &edge.to.neighbours, "outgoing")
+            checker.checkEdgeEnd(edge, edge.from, &edge.from.inEdges, &edge.from.inNeighbours, "incoming")
+        }
+    }
+}
+
+func (checker *checker) checkEdgeEnd(edge *Edge, node *Node, edges *[]*Edge, ends *NodeSet, kind string) {
+    for _, localEdge := range *edges {
+        if localEdge == edge {
+            return
+        }
+    }
+    checker.report(node, func() {
+        for _, localEdge := range *edges {
+            if localEdge == edge {
+                return
+            }
+        }
+        *edges = append(*edges, edge)
+        *ends = append(*ends, edge.Other(node))
+    }, "is missing the %s edge %s", kind, edge.string())
+}
+
+func (checker *checker) checkHyperedge(hyperedge *Node) {
+    if len(hyperedge.roles) != len(hyperedge.hypertrail) {
+        checker.report(hyperedge, func() {
+            roles := make([]string, len(hyperedge.hypertrail))
+            copy(roles, hyperedge.roles)
+            hyperedge.roles = roles
+        }, "has %d roles for %d members", len(hyperedge.roles), len(hyperedge.hypertrail))
+    }
+    for _, member := range hyperedge.hypertrail.Unique() {
+        if !checker.alive(member) {
+            checker.report(hyperedge, func() {
+                hyperedge.dropMember(member)
+            }, "goes through the deleted node %s", member.label)
+            continue
+        }
+        if _, ok := member.hyperneighbours.ContainsNode(hyperedge); !ok {
+            checker.report(hyperedge, func() {
+                if _, ok := member.hyperneighbours.ContainsNode(hyperedge); !ok {
+                    member.hyperneighbours = append(member.hyperneighbours, hyperedge)
+                }
+            }, "goes through %s, which does not list it", member.label)
+        }
+    }
+    if !hyperedge.ordered && len(hyperedge.hypertrail.Unique()) != len(hyperedge.hypertrail) {
+        checker.report(hyperedge, func() {
+            members := NodeSet(nil)
+            roles := []string(nil)
+            for i, member := range hyperedge.hypertrail {
+                if _, ok := members.ContainsNode(member); !ok {
+                    members = append(members, member)
+                    roles = append(roles, hyperedge.roleAt(i))
+                }
+            }
+            hyperedge.hypertrail, hyperedge.roles = members, roles
+        }, "is unordered but has some members more than once")
+    }
+    if (len(hyperedge.tail) == 0) != (len(hyperedge.head) == 0) {
+        checker.report(hyperedge, func() {
+            hyperedge.tail, hyperedge.head = nil, nil
+        }, "has a tail or a head but not both")
+    }
+    for _, member := range hyperedge.tail.Union(hyperedge.head) {
+        if _, ok := hyperedge.hypertrail.ContainsNode(member); !ok {
+            checker.report(hyperedge, func() {
+                if _, ok := hyperedge.hypertrail.ContainsNode(member); !ok {
+                    hyperedge.tail, _ = hyperedge.tail.without(member)
+                    hyperedge.head, _ = hyperedge.head.without(member)
+                    if len(hyperedge.tail) == 0 || len(hyperedge.head) == 0 {
+                        hyperedge.tail, hyperedge.head = nil, nil
+                    }
+                }
+            }, "has %s in its tail or head but not among its members", member.label)
+        }
+    }
+    if checker.dependsOnItself(hyperedge) {
+        checker.report(hyperedge, nil, "is a member of itself")
+        return
+    }
+    if len(hyperedge.hypertrail) == 0 || !checker.rooted(hyperedge) || !checker.rooted(hyperedge.hypertrail...) {
+        return
+    }
+    ancestor := hyperedgeParent(hyperedge.hypertrail)
+    if ancestor != nil && ancestor != hyperedge.parent &&
+        ancestor != hyperedge && !ancestor.isDescendantOf(hyperedge) && checker.alive(hyperedge.parent) {
+        checker.report(hyperedge, func() {
+            hyperedge.realign()
+        }, "is under %s instead of the common ancestor %s of its members", hyperedge.parent.label, ancestor.label)
+    }
+}
+
+// dependsOnItself is DependsOn made safe against cycles, which DependsOn
+// would follow forever.
+func (checker *checker) dependsOnItself(hyperedge *Node) bool {
+    visited := NewNodeIndex()
+    var visit func(*Node) bool
+    visit = func(current *Node) bool {
+        for _, member := range current.hypertrail {
+            if member == hyperedge {
+                return true
+            }
+            if visited.Add(member) && visit(member) {
+                return true
+            }
+        }
+        return false
+    }
+    return visit(hyperedge)
+}
+
+func (checker *checker) checkHyperneighbours(node *Node) {
+    for _, hyperedge := range node.hyperneighbours.Unique() {
+        if _, ok := hyperedge.hypertrail.ContainsNode(node); !ok || !checker.alive(hyperedge) {
+            checker.report(node, func() {
+                node.hyperneighbours, _ = node.hyperneighbours.without(hyperedge)
+            }, "lists the hyperedge %s, which does not go through it", hyperedge.label)
+        }
+    }
+    if len(node.hyperneighbours.Unique()) != len(node.hyperneighbours) {
+        checker.report(node, func() {
+            node.hyperneighbours = node.hyperneighbours.Unique()
+        }, "lists some hyperedges more than once")
+    }
+}
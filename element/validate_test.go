@@ -0,0 +1,81 @@
+package element_test
+import (
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+func createValidGraph() (g, a, b, c, e *element.Node) {
+    g = element.NewGraph("g")
+    a = g.NewSubGraph("a")
+    b = a.NewMutualNeighbour("b")
+    c = b.NewNeighbour("c")
+    e = g.ConnectNewDirectedHyperedge("e", element.NewNodeSet(a, b), element.NewNodeSet(c))
+    e.SetRole(c, "product")
+    return
+}
+
+func TestValidateSound(t *testing.T) {
+    g, a, _, _, e := createValidGraph()
+    s := g.NewSubGraph("s")
+    a.MoveTo(s)
+    g.ConnectNewHyperedge("about", element.NewNodeSet(e, s))
+    a.Delete()
+    if violations := g.Database().Validate(); len(violations) != 0 {
+        t.Error("expected no violations, got", violations)
+    }
+}
+
+func TestValidateAndRepair(t *testing.T) {
+    testData := []struct {
+        name string
+        corrupt func(g, a, b, c, e *element.Node)
+        expected string
+        repairable bool
+    }{
+        {"lost parent", func(g, a, b, c, e *element.Node) {
+            g.DropSubnodeUnchecked(a)
+        }, "a: is missing from the subnodes of its parent g", true},
+        {"wrong parent", func(g, a, b, c, e *element.Node) {
+            b.SetParentUnchecked(a)
+        }, "g: holds b, which does not have it as parent", true},
+        {"half hyperedge", func(g, a, b, c, e *element.Node) {
+            c.DropHyperneighbourUnchecked(e)
+        }, "e: goes through c, which does not list it", true},
+        {"stray hyperedge", func(g, a, b, c, e *element.Node) {
+            c.AddHyperneighbourUnchecked(b)
+        }, "c: lists the hyperedge b, which does not go through it", true},
+        {"half undirected edge", func(g, a, b, c, e *element.Node) {
+            b.DropEdgeUnchecked(a.EdgeTo(b))
+        }, "b: is missing the outgoing edge a - b", true},
+        {"containment cycle", func(g, a, b, c, e *element.Node) {
+            g.SetParentUnchecked(c)
+            c.SetParentUnchecked(g)
+        }, "c: is contained in itself", false},
+    }
+    for _, data := range testData {
+        g, a, b, c, e := createValidGraph()
+        db := g.Database()
+        data.corrupt(g, a, b, c, e)
+
+        violations := db.Validate()
+        found := false
+        for _, violation := range violations {
+            if data.expected == violation.String() {
+                found = true
+                if data.repairable != violation.Repairable() {
+                    t.Error(data.name, "expected repairable to be", data.repairable)
+                }
+            }
+        }
+        if !found {
+            t.Error(data.name, "expected", data.expected, "got", violations)
+        }
+        left := db.Repair()
+        if data.repairable && len(left) != 0 {
+            t.Error(data.name, "expected everything repaired, left", left)
+        }
+        if !data.repairable && len(left) == 0 {
+            t.Error(data.name, "expected violations to be left")
+        }
+    }
+}
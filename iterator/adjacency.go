@@ -0,0 +1,62 @@
+package iterator
+
+import (
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+// Adjacency decides which nodes an iterator can step to from a given node.
+// All the iterators follow Neighbours unless told otherwise with Via, so the
+// same traversal can run over plain edges, hyperedges or both.
+type Adjacency func(*element.Node) element.NodeSet
+
+// Neighbours follows the regular edges.
+func Neighbours(node *element.Node) element.NodeSet {
+    return node.Neighbours()
+}
+
+// InNeighbours follows the regular edges backwards, from their target to
+// their source.
+func InNeighbours(node *element.Node) element.NodeSet {
+    return node.InNeighbours()
+}
+
+// AllNeighbours follows the regular edges both ways, as if none of them was
+// directed.
+func AllNeighbours(node *element.Node) element.NodeSet {
+    return node.Neighbours().Union(node.InNeighbours())
+}
+
+// Hyper steps from a node to the hyperedges going through it, and from a
+// hyperedge to the nodes it goes through. The hyperedges are delivered as
+// nodes of the traversal, in between their members, so hyperedges over other
+// hyperedges are reached like any other member.
+func Hyper(node *element.Node) element.NodeSet {
+    return node.HyperNeighbours().Union(node.Hypertrail())
+}
+
+// HyperMembers steps from a node directly to the other members of the
+// hyperedges going through it, without delivering the hyperedges.
+func HyperMembers(node *element.Node) element.NodeSet {
+    trails := []element.NodeSet(nil)
+    for _, hyperedge := range node.HyperNeighbours() {
+        trails = append(trails, hyperedge.Hypertrail())
+    }
+    return element.NodeSet(nil).Union(trails...).Difference(element.NewNodeSet(node))
+}
+
+// Combine follows everything any of the given adjacencies leads to, e.g.
+// Combine(Neighbours, HyperMembers) counts both edges and hyperedges.
+func Combine(adjacencies ...Adjacency) Adjacency {
+    return func(node *element.Node) element.NodeSet {
+        sets := make([]element.NodeSet, len(adjacencies))
+        for i, adjacency := range adjacencies {
+            sets[i] = adjacency(node)
+        }
+        return element.NodeSet(nil).Union(sets...)
+    }
+}
+
+// Subnodes steps down the containment tree.
+func Subnodes(node *element.Node) element.NodeSet {
+    return node.Subnodes()
+}
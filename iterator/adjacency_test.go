@@ -0,0 +1,138 @@
+package iterator_test
+import (
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+    "github.com/yet-another-project/hypergraphdb/iterator"
+)
+
+// createHyperGraph returns a graph where a, b and c share the hyperedge e,
+// c and d share the hyperedge f, while a and x are connected by a plain
+// edge.
+func createHyperGraph() (a, b, c, d, e, f, x *element.Node) {
+    g := element.NewGraph("g")
+    a = g.NewSubGraph("a")
+    b = g.NewSubGraph("b")
+    c = g.NewSubGraph("c")
+    d = g.NewSubGraph("d")
+    x = a.NewMutualNeighbour("x")
+    e = g.ConnectNewHyperedge("e", element.NewNodeSet(a, b, c))
+    f = g.ConnectNewHyperedge("f", element.NewNodeSet(c, d))
+    return
+}
+
+func TestHyperAdjacency(t *testing.T) {
+    a, b, c, d, e, f, x := createHyperGraph()
+
+    testData := []struct {
+        name string
+        adjacency iterator.Adjacency
+        node *element.Node
+        expected element.NodeSet
+    }{
+        {"Neighbours", iterator.Neighbours, a, element.NewNodeSet(x)},
+        {"Hyper", iterator.Hyper, a, element.NewNodeSet(e)},
+        {"Hyper", iterator.Hyper, c, element.NewNodeSet(e, f)},
+        {"Hyper", iterator.Hyper, e, element.NewNodeSet(a, b, c)},
+        {"HyperMembers", iterator.HyperMembers, c, element.NewNodeSet(a, b, d)},
+        {"HyperMembers", iterator.HyperMembers, x, nil},
+        {"Combine", iterator.Combine(iterator.Neighbours, iterator.HyperMembers), a, element.NewNodeSet(x, b, c)},
+    }
+    for _, data := range testData {
+        actual := data.adjacency(data.node)
+        if data.expected.String() != actual.String() {
+            t.Error(data.name, "of", data.node.Label(), "expected", data.expected, "got", actual)
+        }
+    }
+}
+
+func TestReverseIterators(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewNeighbour("b")
+    c := b.NewNeighbour("c")
+    d := g.NewSubGraph("d")
+    d.ConnectNeighbour(b)
+
+    testData := []struct {
+        name string
+        it iterator.I
+        expected []*element.Node
+    }{
+        {"BFS forward", iterator.NewBFS(c), []*element.Node{c}},
+        {"BFS reverse", iterator.NewBFS(c).Via(iterator.InNeighbours), []*element.Node{c, b, a, d}},
+        {"BFS undirected", iterator.NewBFS(a).Via(iterator.AllNeighbours), []*element.Node{a, b, c, d}},
+        {"DFS reverse", iterator.NewLinearDFS(c).Via(iterator.InNeighbours), []*element.Node{a, d, b, c}},
+    }
+    for _, data := range testData {
+        for i := range data.expected {
+            node, _ := data.it.Next()
+            if data.expected[i] != node {
+                t.Error(data.name, "expected to deliver", data.expected[i], "but instead", node)
+            }
+        }
+        if node, ok := data.it.Next(); ok {
+            t.Error(data.name, "expected to end, got", node)
+        }
+    }
+}
+
+func TestHyperIterators(t *testing.T) {
+    a, b, c, d, e, f, x := createHyperGraph()
+
+    testData := []struct {
+        name string
+        it iterator.I
+        expected []*element.Node
+    }{
+        {"BFS plain", iterator.NewBFS(a), []*element.Node{a, x}},
+        {"BFS hyper", iterator.NewBFS(a).Via(iterator.Hyper), []*element.Node{a, e, b, c, f, d}},
+        {"BFS members", iterator.NewBFS(a).Via(iterator.HyperMembers), []*element.Node{a, b, c, d}},
+        {"BFS both", iterator.NewBFS(x).Via(iterator.Combine(iterator.Neighbours, iterator.HyperMembers)), []*element.Node{x, a, b, c, d}},
+        {"DFS members", iterator.NewLinearDFS(a).Via(iterator.HyperMembers), []*element.Node{d, c, b, a}},
+        {"IDDFS hyper", iterator.NewIDDFS(d).Via(iterator.Hyper), []*element.Node{d, f, c, e, a, b}},
+    }
+    for _, data := range testData {
+        for i := range data.expected {
+            node, _ := data.it.Next()
+            if data.expected[i] != node {
+                t.Error(data.name, "expected to deliver", data.expected[i], "but instead", node)
+            }
+        }
+        if node, ok := data.it.Next(); ok {
+            t.Error(data.name, "expected to end, got", node)
+        }
+    }
+}
+
+func TestHyperedgesOverHyperedges(t *testing.T) {
+    a, b, c, d, e, f, x := createHyperGraph()
+    g := a.Parent()
+    about := g.ConnectNewHyperedge("about", element.NewNodeSet(e, x))
+
+    it := iterator.NewBFS(d).Via(iterator.Hyper)
+    testData := []*element.Node{d, f, c, e, about, a, b, x, nil}
+    for i := range testData {
+        node, _ := it.Next()
+        if testData[i] != node {
+            t.Error("expected to deliver", testData[i], "but instead", node)
+        }
+    }
+    members := iterator.HyperMembers(e)
+    if len(members) != 1 || x != members[0] {
+        t.Error("expected e to lead to x through about, got", members)
+    }
+}
+
+func TestHyperRecursiveDFS(t *testing.T) {
+    a, b, c, d, _, _, _ := createHyperGraph()
+
+    it := iterator.NewRecursiveDFS(a).Via(iterator.HyperMembers)
+    testData := []*element.Node{d, c, b, a, nil}
+
+    for i := range testData {
+        node, _ := it.Next()
+        if testData[i] != node {
+            t.Error("DFS expected to deliver", testData[i], "but instead", node)
+        }
+    }
+}
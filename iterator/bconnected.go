@@ -0,0 +1,68 @@
+package iterator
+
+import (
+    "iter"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+// BConnected follows directed hyperedges the way rules fire: the head of a
+// hyperedge is reached only once every node of its tail has been reached.
+// It delivers the start nodes first, then the nodes they B-connect to, in the
+// order the hyperedges fire. Regular edges and undirected hyperedges are not
+// followed.
+type BConnected struct {
+    queue element.NodeSet
+    reached element.NodeIndex
+    tailsReached map[*element.Node]int
+    via map[*element.Node]*element.Node
+    last *element.Node
+}
+
+func NewBConnected(start ...*element.Node) *BConnected {
+    it := &BConnected{
+        reached: element.NewNodeIndex(),
+        tailsReached: make(map[*element.Node]int),
+        via: make(map[*element.Node]*element.Node),
+    }
+    for _, node := range start {
+        if it.reached.Add(node) {
+            it.queue = append(it.queue, node)
+        }
+    }
+    return it
+}
+
+func (it *BConnected) Next() (*element.Node, bool) {
+    if len(it.queue) == 0 {
+        return nil, false
+    }
+    node := it.queue[0]
+    it.queue = it.queue[1:]
+    it.last = node
+    for _, hyperedge := range node.HyperNeighbours() {
+        if _, ok := hyperedge.Tail().ContainsNode(node); !ok {
+            continue
+        }
+        it.tailsReached[hyperedge]++
+        if it.tailsReached[hyperedge] < len(hyperedge.Tail()) {
+            continue
+        }
+        for _, head := range hyperedge.Head() {
+            if it.reached.Add(head) {
+                it.via[head] = hyperedge
+                it.queue = append(it.queue, head)
+            }
+        }
+    }
+    return node, true
+}
+
+// Hyperedge is the hyperedge whose firing reached the node last returned by
+// Next, or nil for the start nodes.
+func (it *BConnected) Hyperedge() *element.Node {
+    return it.via[it.last]
+}
+
+func (it *BConnected) All() iter.Seq[*element.Node] {
+    return all(it.Next)
+}
@@ -0,0 +1,44 @@
+package iterator_test
+import (
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+    "github.com/yet-another-project/hypergraphdb/iterator"
+)
+
+func TestBConnected(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+    d := g.NewSubGraph("d")
+    e := g.NewSubGraph("e")
+    a.ConnectNeighbour(e)
+    r := g.ConnectNewDirectedHyperedge("r", element.NewNodeSet(a, b), element.NewNodeSet(c))
+    s := g.ConnectNewDirectedHyperedge("s", element.NewNodeSet(c), element.NewNodeSet(d))
+    u := g.ConnectNewDirectedHyperedge("u", element.NewNodeSet(d, e), element.NewNodeSet(a))
+
+    testData := []struct {
+        name string
+        it *iterator.BConnected
+        expected []*element.Node
+        via []*element.Node
+    }{
+        {"whole tail", iterator.NewBConnected(a, b), []*element.Node{a, b, c, d}, []*element.Node{nil, nil, r, s}},
+        {"partial tail", iterator.NewBConnected(a), []*element.Node{a}, []*element.Node{nil}},
+        {"chain", iterator.NewBConnected(c, e, c), []*element.Node{c, e, d, a}, []*element.Node{nil, nil, s, u}},
+    }
+    for _, data := range testData {
+        for i := range data.expected {
+            node, _ := data.it.Next()
+            if data.expected[i] != node {
+                t.Error(data.name, "expected to deliver", data.expected[i], "but instead", node)
+            }
+            if data.via[i] != data.it.Hyperedge() {
+                t.Error(data.name, "expected", node, "through", data.via[i], "got", data.it.Hyperedge())
+            }
+        }
+        if node, ok := data.it.Next(); ok {
+            t.Error(data.name, "expected to end, got", node)
+        }
+    }
+}
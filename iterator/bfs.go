@@ -0,0 +1,76 @@
+package iterator
+
+import (
+    "iter"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+// NoDepthLimit lets the bounded iterators run over the whole graph.
+const NoDepthLimit = -1
+
+// BFS delivers the start node first, then all its neighbours, then their
+// neighbours, and so on.
+type BFS struct {
+    adjacency Adjacency
+    maxDepth int
+    queue element.NodeSet
+    depths map[*element.Node]int
+    lastDepth int
+}
+
+func NewBFS(n *element.Node) *BFS {
+    return NewBoundedBFS(n, NoDepthLimit)
+}
+
+// NewBoundedBFS does not go further than maxDepth hops away from n.
+func NewBoundedBFS(n *element.Node, maxDepth int) *BFS {
+    it := &BFS{
+        adjacency: Neighbours,
+        maxDepth: maxDepth,
+        queue: element.NewNodeSet(n),
+        depths: map[*element.Node]int{n: 0},
+        lastDepth: -1,
+    }
+    return it
+}
+
+// Via sets what the iterator counts as adjacent nodes. It has to be called
+// before the iteration starts.
+func (it *BFS) Via(adjacency Adjacency) *BFS {
+    it.adjacency = adjacency
+    return it
+}
+
+func (it *BFS) Next() (*element.Node, bool) {
+    if len(it.queue) == 0 {
+        return nil, false
+    }
+    node := it.queue[0]
+    it.queue = it.queue[1:]
+    it.lastDepth = it.depths[node]
+    if it.maxDepth != NoDepthLimit && it.lastDepth >= it.maxDepth {
+        return node, true
+    }
+    for _, neighbour := range it.adjacency(node) {
+        if _, ok := it.depths[neighbour]; !ok {
+            it.depths[neighbour] = it.lastDepth + 1
+            it.queue = append(it.queue, neighbour)
+        }
+    }
+    return node, true
+}
+
+// Depth is the number of hops between the start node and the node last
+// returned by Next.
+func (it *BFS) Depth() int {
+    return it.lastDepth
+}
+
+func (it *BFS) All() iter.Seq[*element.Node] {
+    return all(it.Next)
+}
+
+// WithDepth yields the remaining nodes together with their depth.
+func (it *BFS) WithDepth() iter.Seq2[*element.Node, int] {
+    return withDepth(it.Next, it.Depth)
+}
@@ -0,0 +1,206 @@
+package iterator_test
+import (
+    "context"
+    "fmt"
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+    "github.com/yet-another-project/hypergraphdb/iterator"
+)
+
+func TestBFSIteratorSequential(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := a.NewMutualNeighbour("c")
+    d := b.NewNeighbour("d")
+    e := d.NewNeighbour("e")
+    c.ConnectNeighbour(d)
+
+    it := iterator.NewBFS(a)
+    testData := []*element.Node{a, b, c, d, e, nil}
+    depths := []int{0, 1, 1, 2, 3}
+
+    for i := range testData {
+        node, _ := it.Next()
+        if testData[i] != node {
+            t.Error("BFS expected to deliver", testData[i], "but instead", node)
+        }
+        if node != nil && depths[i] != it.Depth() {
+            t.Error("expected", node, "at depth", depths[i], "got", it.Depth())
+        }
+    }
+}
+
+func TestBFSIteratorWithCycleGraph(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := g.NewSubGraph("b")
+    c := g.NewSubGraph("c")
+
+    a.ConnectNeighbour(b)
+    b.ConnectNeighbour(c)
+    c.ConnectNeighbour(a)
+
+    it := iterator.NewBFS(b)
+    testData := []*element.Node{b, c, a, nil}
+
+    for i := range testData {
+        node, _ := it.Next()
+        if testData[i] != node {
+            t.Error("BFS expected to deliver", testData[i], "but instead", node)
+        }
+    }
+}
+
+func TestBoundedBFSIterator(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewNeighbour("b")
+    c := b.NewNeighbour("c")
+    c.NewNeighbour("d")
+
+    testData := [][]*element.Node{
+        {a, nil},
+        {a, b, nil},
+        {a, b, c, nil},
+    }
+    for maxDepth := range testData {
+        it := iterator.NewBoundedBFS(a, maxDepth)
+        for i := range testData[maxDepth] {
+            node, _ := it.Next()
+            if testData[maxDepth][i] != node {
+                t.Error("BFS limited to", maxDepth, "expected to deliver", testData[maxDepth][i], "but instead", node)
+            }
+        }
+    }
+}
+
+func TestBFSIteratorChannel(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := a.NewMutualNeighbour("c")
+
+    it := iterator.NewStreamer(iterator.NewBFS(a))
+    go it.Run(context.Background())
+    testData := []*element.Node{a, b, c}
+
+    i := 0
+    for node := range it.Stream() {
+        if testData[i] != node {
+            t.Error("BFS expected to deliver", testData[i], "but instead", node)
+        }
+        i++
+    }
+    if i != len(testData) {
+        t.Error("expected", len(testData), "nodes, got", i)
+    }
+}
+
+func TestBFSIteratorChannelClose(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    a.NewMutualNeighbour("b")
+    a.NewMutualNeighbour("c")
+
+    it := iterator.NewStreamer(iterator.NewBFS(a))
+    go it.Run(context.Background())
+    if node := <-it.Stream(); a != node {
+        t.Error("BFS expected to deliver a, but instead", node)
+    }
+    it.Close()
+    it.Close()
+    for _ = range it.Stream() {
+    }
+}
+
+func BenchmarkRandomBFS(b *testing.B) {
+    for _, size := range scalingSizes {
+        b.Run(fmt.Sprint(size), func(b *testing.B) {
+            _, graph := createRandomGraph(size, 8)
+            b.ResetTimer()
+            for i := 0; i < b.N; i++ {
+                it := iterator.NewBFS(graph)
+                for range it.All() {
+                }
+            }
+        })
+    }
+}
+
+// run with -race: every step sees a consistent node, whatever writers do
+func TestBFSIteratorConcurrentWrites(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    done := make(chan bool)
+    go func() {
+        defer close(done)
+        last := a
+        for i := 0; i < 200; i++ {
+            next := last.NewMutualNeighbour(fmt.Sprint(i))
+            if i%4 == 3 {
+                last.DisconnectMutual(next)
+                next.Delete()
+                continue
+            }
+            last = next
+        }
+    }()
+    for i := 0; i < 20; i++ {
+        for node := range iterator.NewBFS(a).All() {
+            if node == nil {
+                t.Fatal("BFS delivered nil")
+            }
+        }
+    }
+    <-done
+}
+
+// a traversal over a snapshot sees one state, whatever writers do meanwhile
+func TestBFSIteratorSnapshot(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    last := a
+    for i := 0; i < 50; i++ {
+        last = last.NewMutualNeighbour(fmt.Sprint(i))
+    }
+    snapshot := a.Snapshot()
+    done := make(chan bool)
+    go func() {
+        defer close(done)
+        for _, node := range g.Subnodes() {
+            node.NewMutualNeighbour("x")
+            if node != a {
+                node.Delete()
+            }
+        }
+    }()
+    count := 0
+    for node := range iterator.NewBFS(snapshot).All() {
+        if node.Database() != snapshot.Database() {
+            t.Fatal("left the snapshot at", node)
+        }
+        count++
+    }
+    <-done
+    if count != 51 {
+        t.Error("expected 51 nodes, got", count)
+    }
+}
+
+func TestBFSIteratorAsOf(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    version := g.Database().Version()
+    b.NewNeighbour("c")
+    b.Delete()
+
+    var delivered []string
+    for node := range iterator.NewBFS(a.AsOf(version)).All() {
+        delivered = append(delivered, node.Label())
+    }
+    if fmt.Sprint(delivered) != "[a b]" {
+        t.Error("expected a and b as they were, got", delivered)
+    }
+}
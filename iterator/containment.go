@@ -0,0 +1,131 @@
+package iterator
+
+import (
+    "iter"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+type Order int
+
+const (
+    PreOrder Order = iota //parents before their subnodes
+    PostOrder //subnodes before their parents
+    LevelOrder //all the nodes nested n levels deep before those nested n+1
+)
+
+// Containment walks the tree of subgraphs nested under a node, the root
+// included. Path tells how each delivered node is nested under the root.
+type Containment struct {
+    root *element.Node
+    order Order
+    started bool
+    contextStack iteratorContextStack
+    queue element.NodeSet
+    last *element.Node
+}
+
+func NewContainment(root *element.Node, order Order) *Containment {
+    it := &Containment{
+        root: root,
+        order: order,
+        contextStack: iteratorContextStack(nil),
+    }
+    return it
+}
+
+func (it *Containment) Next() (*element.Node, bool) {
+    switch it.order {
+    case PreOrder:
+        it.last = it.nextPreOrder()
+    case PostOrder:
+        it.last = it.nextPostOrder()
+    case LevelOrder:
+        it.last = it.nextLevelOrder()
+    default:
+        it.last = nil
+    }
+    return it.last, it.last != nil
+}
+
+// nextPreOrder delivers nodes as they are pushed, the frame of each node
+// staying on the stack until all its subnodes have been delivered.
+func (it *Containment) nextPreOrder() *element.Node {
+    if !it.started {
+        it.started = true
+        it.contextStack.PushNode(it.root, Subnodes)
+        return it.root
+    }
+    for len(it.contextStack) > 0 {
+        it.contextStack.AdvanceNeighbour()
+        if it.contextStack.HasMoreNeighbours() {
+            it.contextStack.PushNeighbour(Subnodes)
+            return it.contextStack.TopNode()
+        }
+        it.contextStack.PopNode()
+    }
+    return nil
+}
+
+func (it *Containment) nextPostOrder() *element.Node {
+    if !it.started {
+        it.started = true
+        it.contextStack.PushNode(it.root, Subnodes)
+    }
+    for len(it.contextStack) > 0 {
+        it.contextStack.AdvanceNeighbour()
+        if !it.contextStack.HasMoreNeighbours() {
+            return it.contextStack.PopNode()
+        }
+        it.contextStack.PushNeighbour(Subnodes)
+    }
+    return nil
+}
+
+func (it *Containment) nextLevelOrder() *element.Node {
+    if !it.started {
+        it.started = true
+        it.queue = element.NewNodeSet(it.root)
+    }
+    if len(it.queue) == 0 {
+        return nil
+    }
+    node := it.queue[0]
+    it.queue = append(it.queue[1:], node.Subnodes()...)
+    return node
+}
+
+// Path returns the chain of nodes from the root down to the node last
+// returned by Next, both included. It returns nil if that node has been
+// deleted or moved out of the root since.
+func (it *Containment) Path() element.NodeSet {
+    if it.last == nil {
+        return nil
+    }
+    path := element.NewNodeSet(it.last)
+    for node := it.last; node != it.root; {
+        node = node.Parent()
+        if node == nil {
+            return nil
+        }
+        path = append(path, node)
+    }
+    for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+        path[i], path[j] = path[j], path[i]
+    }
+    return path
+}
+
+func (it *Containment) All() iter.Seq[*element.Node] {
+    return all(it.Next)
+}
+
+// WithPath yields the remaining nodes together with their Path.
+func (it *Containment) WithPath() iter.Seq2[*element.Node, element.NodeSet] {
+    return func(yield func(*element.Node, element.NodeSet) bool) {
+        for node, ok := it.Next(); ok; node, ok = it.Next() {
+            if !yield(node, it.Path()) {
+                return
+            }
+        }
+    }
+}
@@ -0,0 +1,102 @@
+package iterator_test
+import (
+    "context"
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+    "github.com/yet-another-project/hypergraphdb/iterator"
+)
+
+func createContainmentTree() (g, a, b, c, d, e, f *element.Node) {
+    g = element.NewGraph("g")
+    a = g.NewSubGraph("a")
+    b = a.NewSubGraph("b")
+    c = a.NewSubGraph("c")
+    d = c.NewSubGraph("d")
+    e = g.NewSubGraph("e")
+    f = e.NewSubGraph("f")
+    return
+}
+
+func TestContainmentOrders(t *testing.T) {
+    g, a, b, c, d, e, f := createContainmentTree()
+
+    testData := []struct {
+        order iterator.Order
+        expected []*element.Node
+    }{
+        {iterator.PreOrder, []*element.Node{g, a, b, c, d, e, f, nil}},
+        {iterator.PostOrder, []*element.Node{b, d, c, a, f, e, g, nil}},
+        {iterator.LevelOrder, []*element.Node{g, a, e, b, c, f, d, nil}},
+    }
+    for _, data := range testData {
+        it := iterator.NewContainment(g, data.order)
+        for i := range data.expected {
+            node, _ := it.Next()
+            if data.expected[i] != node {
+                t.Error("order", data.order, "expected to deliver", data.expected[i], "but instead", node)
+            }
+        }
+    }
+}
+
+func TestContainmentPath(t *testing.T) {
+    g, a, _, c, d, _, _ := createContainmentTree()
+
+    for _, order := range []iterator.Order{iterator.PreOrder, iterator.PostOrder, iterator.LevelOrder} {
+        it := iterator.NewContainment(a, order)
+        for node := range it.All() {
+            path := it.Path()
+            if path[0] != a || path[len(path)-1] != node {
+                t.Error("order", order, "wrong path to", node.Label(), path)
+            }
+            if node == d && element.NewNodeSet(a, c, d).String() != path.String() {
+                t.Error("order", order, "expected path [a, c, d], got", path)
+            }
+            if _, ok := path.ContainsNode(g); ok {
+                t.Error("order", order, "path goes above the root", path)
+            }
+        }
+        if nil != it.Path() {
+            t.Error("expected no path once the walk is over")
+        }
+    }
+}
+
+func TestContainmentPathOfRemovedNode(t *testing.T) {
+    g, a, b, c, _, _, _ := createContainmentTree()
+
+    it := iterator.NewContainment(a, iterator.PreOrder)
+    for node := range it.All() {
+        if node == b {
+            b.MoveTo(g)
+            if nil != it.Path() {
+                t.Error("expected no path to a node moved out of the root, got", it.Path())
+            }
+        }
+        if node == c {
+            c.Delete()
+            if nil != it.Path() {
+                t.Error("expected no path to a deleted node, got", it.Path())
+            }
+        }
+    }
+}
+
+func TestContainmentChannel(t *testing.T) {
+    g, a, b, c, d, e, f := createContainmentTree()
+
+    it := iterator.NewStreamer(iterator.NewContainment(g, iterator.PreOrder))
+    go it.Run(context.Background())
+    testData := []*element.Node{g, a, b, c, d, e, f}
+
+    i := 0
+    for node := range it.Stream() {
+        if testData[i] != node {
+            t.Error("expected to deliver", testData[i], "but instead", node)
+        }
+        i++
+    }
+    if i != len(testData) {
+        t.Error("expected", len(testData), "nodes, got", i)
+    }
+}
@@ -1,4 +1,14 @@
 /*
 Package iterator provides iterating heuristics for hypergraphs.
+
+Iterators may run while other goroutines change the graph. Each step reads
+the adjacency of the current node under the database lock, so a step never
+sees a half-done change, but a traversal as a whole is not a snapshot: nodes
+added behind it are missed, nodes removed after being queued are still
+delivered. Iterate over a Snapshot for a consistent view, e.g.
+
+    for node := range iterator.NewBFS(graph.Snapshot()).All() {
+        ...
+    }
 */
 package iterator
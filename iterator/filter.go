@@ -0,0 +1,44 @@
+package iterator
+
+import (
+    "iter"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+// Filter delivers only the nodes of another iterator which keep accepts. The
+// traversal itself is not affected: rejected nodes are still walked through.
+type Filter struct {
+    it I
+    keep func(*element.Node) bool
+}
+
+func NewFilter(it I, keep func(*element.Node) bool) *Filter {
+    return &Filter{it: it, keep: keep}
+}
+
+// NewTypeFilter keeps the nodes of any of the given types, e.g.
+// NewTypeFilter(it, element.HyperedgeNode) delivers only the hyperedges.
+func NewTypeFilter(it I, types ...element.NodeType) *Filter {
+    return NewFilter(it, func(node *element.Node) bool {
+        nodeType := node.Type()
+        for _, t := range types {
+            if t == nodeType {
+                return true
+            }
+        }
+        return false
+    })
+}
+
+func (f *Filter) Next() (*element.Node, bool) {
+    for node, ok := f.it.Next(); ok; node, ok = f.it.Next() {
+        if f.keep(node) {
+            return node, true
+        }
+    }
+    return nil, false
+}
+
+func (f *Filter) All() iter.Seq[*element.Node] {
+    return all(f.Next)
+}
@@ -0,0 +1,35 @@
+package iterator_test
+import (
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+    "github.com/yet-another-project/hypergraphdb/iterator"
+)
+
+func TestTypeFilter(t *testing.T) {
+    a, b, c, d, e, f, _ := createHyperGraph()
+
+    testData := []struct {
+        name string
+        it iterator.I
+        expected []*element.Node
+    }{
+        {"hyperedges", iterator.NewTypeFilter(iterator.NewBFS(a).Via(iterator.Hyper), element.HyperedgeNode), []*element.Node{e, f}},
+        {"leaves", iterator.NewTypeFilter(iterator.NewBFS(d).Via(iterator.Hyper), element.Leaf), []*element.Node{d, c, b}},
+        {"none", iterator.NewTypeFilter(iterator.NewBFS(a), element.SubGraph), nil},
+    }
+    for _, data := range testData {
+        actual := []*element.Node(nil)
+        for node := range data.it.All() {
+            actual = append(actual, node)
+        }
+        if len(data.expected) != len(actual) {
+            t.Error(data.name, "expected", data.expected, "got", actual)
+            continue
+        }
+        for i := range data.expected {
+            if data.expected[i] != actual[i] {
+                t.Error(data.name, "expected to deliver", data.expected[i], "but instead", actual[i])
+            }
+        }
+    }
+}
@@ -0,0 +1,110 @@
+package iterator
+
+import (
+    "iter"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+// IDDFS is an iterative deepening DFS: it runs depth limited DFS passes with
+// an increasing limit and delivers, on every pass, the nodes first found at
+// that depth. The result comes level by level like BFS. Each pass remembers
+// the shallowest depth it reached every node at, so that it only walks a node
+// again when it finds a shorter path to it; its memory is linear in the
+// number of nodes, like the other DFS iterators.
+type IDDFS struct {
+    start *element.Node
+    adjacency Adjacency
+    maxDepth int
+    limit int
+    contextStack iteratorContextStack
+    reached map[*element.Node]int
+    previous map[*element.Node]int
+    deliveredOnPass bool
+}
+
+func NewIDDFS(n *element.Node) *IDDFS {
+    return NewBoundedIDDFS(n, NoDepthLimit)
+}
+
+// NewBoundedIDDFS stops after the pass for maxDepth.
+func NewBoundedIDDFS(n *element.Node, maxDepth int) *IDDFS {
+    it := &IDDFS{
+        start: n,
+        adjacency: Neighbours,
+        maxDepth: maxDepth,
+        limit: -1,
+        contextStack: iteratorContextStack(nil),
+        reached: map[*element.Node]int{},
+        deliveredOnPass: true,
+    }
+    return it
+}
+
+// Via sets what the iterator counts as adjacent nodes. It has to be called
+// before the iteration starts.
+func (it *IDDFS) Via(adjacency Adjacency) *IDDFS {
+    it.adjacency = adjacency
+    return it
+}
+
+// nextPass starts the DFS pass for the next depth limit. There is no point in
+// going deeper once a pass has not found anything new. The nodes reached by
+// the pass before are the ones already delivered.
+func (it *IDDFS) nextPass() bool {
+    if !it.deliveredOnPass {
+        return false
+    }
+    if it.maxDepth != NoDepthLimit && it.limit >= it.maxDepth {
+        return false
+    }
+    it.limit++
+    it.deliveredOnPass = false
+    it.previous = it.reached
+    it.reached = map[*element.Node]int{it.start: 0}
+    it.contextStack.PushNode(it.start, it.adjacency)
+    return true
+}
+
+func (it *IDDFS) Next() (*element.Node, bool) {
+    for {
+        if len(it.contextStack) == 0 && !it.nextPass() {
+            return nil, false
+        }
+        depth := len(it.contextStack) - 1
+        if depth == it.limit {
+            node := it.contextStack.PopNode()
+            if _, ok := it.previous[node]; ok {
+                continue
+            }
+            it.deliveredOnPass = true
+            return node, true
+        }
+        it.contextStack.AdvanceNeighbour()
+        if !it.contextStack.HasMoreNeighbours() {
+            it.contextStack.PopNode()
+            continue
+        }
+        // a node is only walked again when this path to it is shorter, so
+        // each node is delivered once, on the pass for its depth
+        neighbour := it.contextStack.CurrentNeighbour()
+        if reached, ok := it.reached[neighbour]; !ok || reached > depth+1 {
+            it.reached[neighbour] = depth + 1
+            it.contextStack.PushNode(neighbour, it.adjacency)
+        }
+    }
+}
+
+// Depth is the number of hops between the start node and the node last
+// returned by Next.
+func (it *IDDFS) Depth() int {
+    return it.limit
+}
+
+func (it *IDDFS) All() iter.Seq[*element.Node] {
+    return all(it.Next)
+}
+
+// WithDepth yields the remaining nodes together with their depth.
+func (it *IDDFS) WithDepth() iter.Seq2[*element.Node, int] {
+    return withDepth(it.Next, it.Depth)
+}
@@ -0,0 +1,122 @@
+package iterator_test
+import (
+    "context"
+    "fmt"
+    "sort"
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+    "github.com/yet-another-project/hypergraphdb/iterator"
+)
+
+func TestIDDFSIteratorLevels(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := a.NewMutualNeighbour("c")
+    d := b.NewNeighbour("d")
+    e := d.NewNeighbour("e")
+    f := c.NewNeighbour("f")
+    // e is reached through a longer path first
+    b.ConnectNeighbour(e)
+
+    it := iterator.NewIDDFS(a)
+    testData := []*element.Node{a, b, c, d, e, f, nil}
+    depths := []int{0, 1, 1, 2, 2, 2}
+
+    for i := range testData {
+        node, _ := it.Next()
+        if testData[i] != node {
+            t.Error("IDDFS expected to deliver", testData[i], "but instead", node)
+        }
+        if node != nil && depths[i] != it.Depth() {
+            t.Error("expected", node, "at depth", depths[i], "got", it.Depth())
+        }
+    }
+}
+
+func TestBoundedIDDFSIterator(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewNeighbour("b")
+    c := b.NewNeighbour("c")
+    c.NewNeighbour("d")
+
+    testData := [][]*element.Node{
+        {a, nil},
+        {a, b, nil},
+        {a, b, c, nil},
+    }
+    for maxDepth := range testData {
+        it := iterator.NewBoundedIDDFS(a, maxDepth)
+        for i := range testData[maxDepth] {
+            node, _ := it.Next()
+            if testData[maxDepth][i] != node {
+                t.Error("IDDFS limited to", maxDepth, "expected to deliver", testData[maxDepth][i], "but instead", node)
+            }
+        }
+    }
+}
+
+func TestIDDFSIteratorMatchesLinearDFS(t *testing.T) {
+    _, start := createRandomGraph(500, 3)
+
+    collect := func(it iterator.I) []int {
+        ids := []int(nil)
+        for node := range it.All() {
+            ids = append(ids, int(node.ID()))
+        }
+        sort.Ints(ids)
+        return ids
+    }
+    expected := collect(iterator.NewLinearDFS(start))
+    actual := collect(iterator.NewIDDFS(start))
+    if fmt.Sprint(expected) != fmt.Sprint(actual) {
+        t.Error("IDDFS and LinearDFS reached different nodes:", len(expected), "vs", len(actual))
+    }
+
+    bfs := iterator.NewBFS(start)
+    iddfs := iterator.NewIDDFS(start)
+    for node := range bfs.All() {
+        other, _ := iddfs.Next()
+        if bfs.Depth() != iddfs.Depth() {
+            t.Error("expected depth", bfs.Depth(), "for", node.Label(), "got", iddfs.Depth(), "for", other)
+            break
+        }
+    }
+}
+
+func BenchmarkRandomIDDFS(b *testing.B) {
+    for _, size := range scalingSizes {
+        b.Run(fmt.Sprint(size), func(b *testing.B) {
+            _, graph := createRandomGraph(size, 8)
+            b.ResetTimer()
+            for i := 0; i < b.N; i++ {
+                it := iterator.NewIDDFS(graph)
+                for range it.All() {
+                }
+            }
+        })
+    }
+}
+
+func TestIDDFSIteratorChannel(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := b.NewMutualNeighbour("c")
+
+    it := iterator.NewStreamer(iterator.NewIDDFS(a))
+    go it.Run(context.Background())
+    testData := []*element.Node{a, b, c}
+
+    i := 0
+    for node := range it.Stream() {
+        if testData[i] != node {
+            t.Error("IDDFS expected to deliver", testData[i], "but instead", node)
+        }
+        i++
+    }
+    if i != len(testData) {
+        t.Error("expected", len(testData), "nodes, got", i)
+    }
+}
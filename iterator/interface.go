@@ -1,19 +1,48 @@
 package iterator
 
 import (
+    "iter"
     "github.com/yet-another-project/hypergraphdb/element"
 )
 
+// I is implemented by all the iterators. Next is a synchronous pull: it
+// returns the next node, or false once the iteration is over. All yields the
+// remaining nodes for range loops:
+//
+//     for node := range it.All() {
+//     }
+//
+// Breaking out of the loop leaves the iterator where it stopped. NewStreamer
+// delivers the nodes on a channel instead.
 type I interface {
-    Stream() <-chan *element.Node
-    Close()
-    Next() *element.Node
-    Run()
+    Next() (*element.Node, bool)
+    All() iter.Seq[*element.Node]
+}
+
+func all(next func() (*element.Node, bool)) iter.Seq[*element.Node] {
+    return func(yield func(*element.Node) bool) {
+        for node, ok := next(); ok; node, ok = next() {
+            if !yield(node) {
+                return
+            }
+        }
+    }
+}
+
+func withDepth(next func() (*element.Node, bool), depth func() int) iter.Seq2[*element.Node, int] {
+    return func(yield func(*element.Node, int) bool) {
+        for node, ok := next(); ok; node, ok = next() {
+            if !yield(node, depth()) {
+                return
+            }
+        }
+    }
 }
 
 type iteratorContext struct {
     contextNode *element.Node
     neighbourIndex int
+    neighbours element.NodeSet
 }
 
 type iteratorContextStack []*iteratorContext
@@ -61,7 +90,7 @@ func (ctx *iteratorContextStack) CurrentNeighbourIndex() int {
 }
 
 func (ctx *iteratorContextStack) HasMoreNeighbours() bool {
-    if len(*ctx) > 0 && len(ctx.TopNode().Neighbours()) > (*ctx)[len(*ctx)-1].neighbourIndex {
+    if len(*ctx) > 0 && len((*ctx)[len(*ctx)-1].neighbours) > (*ctx)[len(*ctx)-1].neighbourIndex {
         return true
     }
     return false
@@ -72,10 +101,10 @@ func (ctx *iteratorContextStack) CurrentNeighbour() *element.Node {
         return nil
     }
     idx := (*ctx)[len(*ctx)-1].neighbourIndex
-    if idx < 0 || idx >= len((*ctx)[len(*ctx)-1].contextNode.Neighbours()) {
+    if idx < 0 || idx >= len((*ctx)[len(*ctx)-1].neighbours) {
         return nil
     }
-    return (*ctx)[len(*ctx)-1].contextNode.Neighbours()[idx]
+    return (*ctx)[len(*ctx)-1].neighbours[idx]
 }
 
 func (ctx *iteratorContextStack) NextNeighbour() *element.Node {
@@ -84,24 +113,23 @@ func (ctx *iteratorContextStack) NextNeighbour() *element.Node {
     }
     ctxFrame := (*ctx)[len(*ctx)-1]
     idx := ctxFrame.neighbourIndex + 1
-    if idx < 0 || idx >= len(ctxFrame.contextNode.Neighbours()) {
+    if idx < 0 || idx >= len(ctxFrame.neighbours) {
         return nil
     }
-    return ctxFrame.contextNode.Neighbours()[idx]
+    return ctxFrame.neighbours[idx]
 }
 
-func (ctx *iteratorContextStack) PushNeighbour() {
-    newctx := &iteratorContext{
-        ctx.CurrentNeighbour(),
-        -1,
-    }
-    *ctx = append(*ctx, newctx)
+func (ctx *iteratorContextStack) PushNeighbour(adjacency Adjacency) {
+    ctx.PushNode(ctx.CurrentNeighbour(), adjacency)
 }
 
-func (ctx *iteratorContextStack) PushNode(node *element.Node) *iteratorContext {
+// PushNode puts node on top of the stack, together with the nodes it leads
+// to according to adjacency.
+func (ctx *iteratorContextStack) PushNode(node *element.Node, adjacency Adjacency) *iteratorContext {
     newctx := &iteratorContext{
         node,
         -1,
+        adjacency(node),
     }
     *ctx = append(*ctx, newctx)
     return newctx
@@ -1,5 +1,6 @@
 package iterator_test
 import (
+    "context"
     "fmt"
     "testing"
     "github.com/yet-another-project/hypergraphdb/element"
@@ -17,7 +18,7 @@ func TestLinearDFSIteratorSequentialPost(t *testing.T) {
     testData := []*element.Node{b, c, d, a, nil}
 
     for i := range testData {
-        node := it.Next()
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -35,7 +36,7 @@ func TestLinearDFSIteratorSequentialDeep(t *testing.T) {
     testData := []*element.Node{d, c, b, a, nil}
 
     for i := range testData {
-        node := it.Next()
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -60,7 +61,7 @@ func TestLinearDFSIteratorSequentialWithCycleGraph(t *testing.T) {
     testData := []*element.Node{c, b, a, nil}
 
     for i := range testData {
-        node := it.Next()
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -76,7 +77,7 @@ func TestLinearDFSIteratorSimpleCircular(t *testing.T) {
     testData := []*element.Node{b, a, nil}
 
     for i := range testData {
-        node := it.Next()
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -85,7 +86,7 @@ func TestLinearDFSIteratorSimpleCircular(t *testing.T) {
     testData = []*element.Node{a, b, nil}
 
     for i := range testData {
-        node := it.Next()
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -99,7 +100,7 @@ func TestLinearDFSIteratorSingleNode(t *testing.T) {
     testData := []*element.Node{a, nil}
 
     for i := range testData {
-        node := it.Next()
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -114,12 +115,52 @@ func BenchmarkFullyConnectedLinearDFS(b *testing.B) {
 
     for i := 0; i < b.N; i++ {
         it := iterator.NewLinearDFS(graph)
-        for node := it.Next(); node != nil; node = it.Next() {
+        for _ = range it.All() {
            // fmt.Println(node)
         }
     }
 }
 
+func benchmarkLinearDFS(b *testing.B, graph *element.Node) {
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        it := iterator.NewLinearDFS(graph)
+        for range it.All() {
+        }
+    }
+}
+
+func BenchmarkChainLinearDFS(b *testing.B) {
+    for _, size := range scalingSizes {
+        b.Run(fmt.Sprint(size), func(b *testing.B) {
+            _, graph := createChainGraph(size)
+            benchmarkLinearDFS(b, graph)
+        })
+    }
+}
+
+func BenchmarkRandomLinearDFS(b *testing.B) {
+    for _, size := range scalingSizes {
+        b.Run(fmt.Sprint(size), func(b *testing.B) {
+            _, graph := createRandomGraph(size, 8)
+            benchmarkLinearDFS(b, graph)
+        })
+    }
+}
+
+func TestLinearDFSIteratorLargeGraph(t *testing.T) {
+    size := 10000
+    _, graph := createChainGraph(size)
+    it := iterator.NewLinearDFS(graph)
+    count := 0
+    for range it.All() {
+        count++
+    }
+    if count != size {
+        t.Error("expected", size, "nodes, got", count)
+    }
+}
+
 func TestLinearDFSIteratorChannelSimple(t *testing.T) {
     g := element.NewGraph("g")
     a := g.NewSubGraph("a")
@@ -127,8 +168,8 @@ func TestLinearDFSIteratorChannelSimple(t *testing.T) {
     c := a.NewMutualNeighbour("c")
     d := a.NewMutualNeighbour("d")
 
-    it := iterator.NewLinearDFS(a)
-    go it.Run()
+    it := iterator.NewStreamer(iterator.NewLinearDFS(a))
+    go it.Run(context.Background())
     testData := []*element.Node{b, c, d, a, nil}
 
     for i := range testData {
@@ -146,8 +187,8 @@ func TestLinearDFSIteratorChannelClose(t *testing.T) {
     a.NewMutualNeighbour("c")
     a.NewMutualNeighbour("d")
 
-    it := iterator.NewLinearDFS(a)
-    go it.Run()
+    it := iterator.NewStreamer(iterator.NewLinearDFS(a))
+    go it.Run(context.Background())
     node := <-it.Stream()
     if b != node {
         t.Error("DFS expected to deliver b, but instead", node)
@@ -1,97 +1,92 @@
 package iterator
 import (
+    "iter"
     "github.com/yet-another-project/hypergraphdb/element"
     "github.com/golang/glog"
 )
 
+// RecursiveDFS delivers the nodes in DFS post-order from a recursive walk.
+// All runs the recursion directly; Next makes the same walk over an explicit
+// context stack, like LinearDFS, so it can be left at any point.
 type RecursiveDFS struct {
     start *element.Node
-    current element.NodeSet
-    visited element.NodeSet
-    stream chan *element.Node
+    started bool
+    adjacency Adjacency
+    visited element.NodeIndex
+    contextStack iteratorContextStack
 }
 
 func NewRecursiveDFS(start *element.Node) *RecursiveDFS {
-    it := &RecursiveDFS{start, element.NodeSet(nil), element.NodeSet(nil), make(chan *element.Node)}
-    it.current = append(it.current, start)
+    return &RecursiveDFS{start: start, adjacency: Neighbours, visited: element.NewNodeIndex()}
+}
+
+// Via sets what the iterator counts as adjacent nodes. It has to be called
+// before the iteration starts.
+func (it *RecursiveDFS) Via(adjacency Adjacency) *RecursiveDFS {
+    it.adjacency = adjacency
     return it
 }
 
-func (it *RecursiveDFS) dfsUtil(pre bool) {
-    stackTop := it.current[len(it.current)-1]
-    glog.V(1).Infoln("started ctx " + stackTop.String())
-    if !it.isVisited(stackTop) && pre {
-        glog.V(2).Infoln("pushing at start of scope " + stackTop.String())
-        it.pushNode(stackTop)
-        status := it.popNode(stackTop)
-        if !status {
-            glog.V(2).Infoln("could not pop node")
-        }
-    }
-    for _, neighbour := range stackTop.Neighbours() {
-            if !it.isVisited(neighbour) && !it.isOnBackPath(neighbour) {
-                it.current = append(it.current, neighbour)
-                it.visited = append(it.visited, neighbour)
-                if pre {
-                    glog.V(2).Infoln("before recursive, pushing " + neighbour.String() + " from ctx " + stackTop.String())
-                    it.pushNode(neighbour)
-                }
-                it.dfsUtil(pre)
-                if !pre {
-                    glog.V(2).Infoln("back from recursive, pushing " + neighbour.String() + " from ctx " + stackTop.String())
-                    it.pushNode(neighbour)
-                }
+// dfsUtil marks nodes as visited when entering them and yields them when
+// leaving, which gives the post-order. It returns false as soon as the walk
+// has to stop, so that the whole recursion unwinds.
+func (it *RecursiveDFS) dfsUtil(node *element.Node, yield func(*element.Node) bool) bool {
+    glog.V(1).Infoln("started ctx", node)
+    it.visited.Add(node)
+    for _, neighbour := range it.adjacency(node) {
+        if !it.visited.Contains(neighbour) {
+            glog.V(2).Infoln("descending to", neighbour, "from ctx", node)
+            if !it.dfsUtil(neighbour, yield) {
+                return false
             }
-    }
-    if !it.isVisited(stackTop) && !pre {
-        glog.V(2).Infoln("pushing before end of scope " + stackTop.String())
-        it.pushNode(stackTop)
-        status := it.popNode(stackTop)
-        if !status {
-            glog.V(2).Infoln("could not pop node")
         }
     }
+    glog.V(1).Infoln("pushing node", node)
+    return yield(node)
 }
 
-func (it *RecursiveDFS) Stream() <-chan *element.Node {
-    go func() {
-        it.dfsUtil(false)
-        close(it.stream)
-    }()
-    return it.stream
-}
-
-func (it *RecursiveDFS) isVisited(node *element.Node) bool {
-    for _, visited := range it.visited {
-        if visited == node {
-            return true
+// All walks the graph. It can only be ranged over once, and not together
+// with Next.
+func (it *RecursiveDFS) All() iter.Seq[*element.Node] {
+    return func(yield func(*element.Node) bool) {
+        if it.visited.Contains(it.start) {
+            return
         }
+        it.dfsUtil(it.start, yield)
     }
-    return false
 }
 
-func (it *RecursiveDFS) isOnBackPath(node *element.Node) bool {
-    for _, prev := range it.current {
-        if prev == node {
-            return true
+// Next enters the nodes in the order dfsUtil does and returns them when
+// leaving them.
+func (it *RecursiveDFS) Next() (*element.Node, bool) {
+    if !it.started {
+        it.started = true
+        if it.visited.Contains(it.start) {
+            return nil, false
+        }
+        it.pushNode(it.start)
+    }
+    for len(it.contextStack) > 0 {
+        it.contextStack.AdvanceNeighbour()
+        for it.contextStack.HasMoreNeighbours() && it.visited.Contains(it.contextStack.CurrentNeighbour()) {
+            it.contextStack.AdvanceNeighbour()
+        }
+        if !it.contextStack.HasMoreNeighbours() {
+            return it.contextStack.PopNode(), true
         }
+        it.pushNode(it.contextStack.CurrentNeighbour())
     }
-    return false
+    return nil, false
 }
 
-func (it *RecursiveDFS) pushNode(node *element.Node) bool {
-    glog.V(1).Infoln("pushing node", node)
-    it.stream<- node
-    it.visited = append(it.visited, node)
-    return true
+func (it *RecursiveDFS) pushNode(node *element.Node) {
+    it.contextStack.PushNode(node, it.adjacency)
+    it.visited.Add(node)
 }
 
-func (it *RecursiveDFS) popNode(node *element.Node) bool {
-    head, tail := it.visited[len(it.visited)-1], it.visited[:len(it.visited)-1]
-    if head == node {
-        it.visited = tail
-        return true
-    }
-    return false
+// Close ends the walk: Next returns false from then on. There is nothing to
+// release, a walk can also just be dropped.
+func (it *RecursiveDFS) Close() {
+    it.started = true
+    it.contextStack = nil
 }
-
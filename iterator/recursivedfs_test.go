@@ -16,9 +16,8 @@ func TestRecursiveDFSIteratorRecursivePost(t *testing.T) {
     it := iterator.NewRecursiveDFS(a)
     testData := []*element.Node{b, c, d, a, nil}
 
-    stream := it.Stream()
     for i := range testData {
-        node := <-stream
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -35,9 +34,8 @@ func TestRecursiveDFSIteratorSequentialDeep(t *testing.T) {
     it := iterator.NewRecursiveDFS(a)
     testData := []*element.Node{d, c, b, a, nil}
 
-    stream := it.Stream()
     for i := range testData {
-        node := <-stream
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -61,9 +59,8 @@ func TestRecursiveDFSIteratorSequentialWithCycleGraph(t *testing.T) {
 
     testData := []*element.Node{c, b, a, nil}
 
-    stream := it.Stream()
     for i := range testData {
-        node := <-stream
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -78,9 +75,8 @@ func TestRecursiveDFSIteratorSimpleCircular(t *testing.T) {
 
     testData := []*element.Node{b, a, nil}
 
-    stream := it.Stream()
     for i := range testData {
-        node := <-stream
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -88,9 +84,8 @@ func TestRecursiveDFSIteratorSimpleCircular(t *testing.T) {
     it = iterator.NewRecursiveDFS(b)
     testData = []*element.Node{a, b, nil}
 
-    stream = it.Stream()
     for i := range testData {
-        node := <-stream
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
@@ -103,15 +98,32 @@ func TestRecursiveDFSIteratorSingleNode(t *testing.T) {
     it := iterator.NewRecursiveDFS(a)
     testData := []*element.Node{a, nil}
 
-    stream := it.Stream()
     for i := range testData {
-        node := <-stream
+        node, _ := it.Next()
         if testData[i] != node {
             t.Error("DFS expected to deliver", testData[i], "but instead", node)
         }
     }
 }
 
+func TestRecursiveDFSNextMatchesAll(t *testing.T) {
+    _, start := createRandomGraph(500, 3)
+
+    recursive := []*element.Node(nil)
+    for node := range iterator.NewRecursiveDFS(start).All() {
+        recursive = append(recursive, node)
+    }
+    it := iterator.NewRecursiveDFS(start)
+    for i, expected := range recursive {
+        if node, _ := it.Next(); node != expected {
+            t.Fatal("expected", expected, "at", i, "got", node)
+        }
+    }
+    if node, ok := it.Next(); ok {
+        t.Error("expected the end, got", node)
+    }
+}
+
 func BenchmarkFullyConnectedRecursiveDFS(b *testing.B) {
     size := 1900
     fmt.Println(size)
@@ -120,10 +132,36 @@ func BenchmarkFullyConnectedRecursiveDFS(b *testing.B) {
 
     for i := 0; i < b.N; i++ {
         it := iterator.NewRecursiveDFS(graph)
-        stream := it.Stream()
-        for ; true; <-stream {
+        for range it.All() {
            // fmt.Println(node)
         }
     }
 }
 
+func benchmarkRecursiveDFS(b *testing.B, graph *element.Node) {
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        it := iterator.NewRecursiveDFS(graph)
+        for range it.All() {
+        }
+    }
+}
+
+func BenchmarkChainRecursiveDFS(b *testing.B) {
+    for _, size := range scalingSizes {
+        b.Run(fmt.Sprint(size), func(b *testing.B) {
+            _, graph := createChainGraph(size)
+            benchmarkRecursiveDFS(b, graph)
+        })
+    }
+}
+
+func BenchmarkRandomRecursiveDFS(b *testing.B) {
+    for _, size := range scalingSizes {
+        b.Run(fmt.Sprint(size), func(b *testing.B) {
+            _, graph := createRandomGraph(size, 8)
+            benchmarkRecursiveDFS(b, graph)
+        })
+    }
+}
+
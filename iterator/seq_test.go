@@ -0,0 +1,84 @@
+package iterator_test
+import (
+    "iter"
+    "testing"
+    "github.com/yet-another-project/hypergraphdb/element"
+    "github.com/yet-another-project/hypergraphdb/iterator"
+)
+
+func TestAllResumesAfterBreak(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewMutualNeighbour("b")
+    c := a.NewMutualNeighbour("c")
+    d := a.NewMutualNeighbour("d")
+
+    it := iterator.NewLinearDFS(a)
+    for node := range it.All() {
+        if b != node {
+            t.Error("expected b, got", node)
+        }
+        break
+    }
+    if node, ok := it.Next(); !ok || c != node {
+        t.Error("expected c, got", node)
+    }
+    testData := []*element.Node{d, a}
+    i := 0
+    for node := range it.All() {
+        if testData[i] != node {
+            t.Error("expected", testData[i], "got", node)
+        }
+        i++
+    }
+    if node, ok := it.Next(); ok || nil != node {
+        t.Error("expected the end, got", node)
+    }
+}
+
+func TestWithDepth(t *testing.T) {
+    g := element.NewGraph("g")
+    a := g.NewSubGraph("a")
+    b := a.NewNeighbour("b")
+    c := b.NewNeighbour("c")
+    testData := []*element.Node{a, b, c}
+
+    for name, it := range map[string]interface{ WithDepth() iter.Seq2[*element.Node, int] }{
+        "BFS": iterator.NewBFS(a),
+        "IDDFS": iterator.NewIDDFS(a),
+    } {
+        i := 0
+        for node, depth := range it.WithDepth() {
+            if testData[i] != node || i != depth {
+                t.Error(name, "expected", testData[i], "at", i, "got", node, "at", depth)
+            }
+            i++
+        }
+        if i != len(testData) {
+            t.Error(name, "expected", len(testData), "nodes, got", i)
+        }
+    }
+}
+
+func TestWithPath(t *testing.T) {
+    g, a, _, c, d, _, _ := createContainmentTree()
+
+    for node, path := range iterator.NewContainment(g, iterator.PostOrder).WithPath() {
+        if node == d && element.NewNodeSet(g, a, c, d).String() != path.String() {
+            t.Error("expected path [g, a, c, d], got", path)
+        }
+    }
+}
+
+func TestRecursiveDFSNextClose(t *testing.T) {
+    _, start := createChainGraph(100)
+
+    it := iterator.NewRecursiveDFS(start)
+    if _, ok := it.Next(); !ok {
+        t.Error("expected a node")
+    }
+    it.Close()
+    if node, ok := it.Next(); ok {
+        t.Error("expected the end after Close, got", node)
+    }
+}
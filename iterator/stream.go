@@ -0,0 +1,103 @@
+package iterator
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "github.com/yet-another-project/hypergraphdb/element"
+)
+
+// ErrClosed is reported by Run and Err when Close stopped the iteration
+// before it reached its end.
+var ErrClosed = errors.New("iterator closed")
+
+// Streamer delivers the nodes of an iterator on a channel: Run feeds Stream
+// until the iteration ends, the context is done or Close is called. In all
+// three cases the stream gets closed and the goroutine running Run returns,
+// whether the consumer still reads or not.
+type Streamer struct {
+    it I
+    stream chan *element.Node
+    closing chan struct{}
+    closeOnce sync.Once
+    mu sync.Mutex
+    err error
+}
+
+func NewStreamer(it I) *Streamer {
+    return &Streamer{
+        it: it,
+        stream: make(chan *element.Node),
+        closing: make(chan struct{}),
+    }
+}
+
+func (s *Streamer) Stream() <-chan *element.Node {
+    return s.stream
+}
+
+// Close stops Run. It never blocks and can be called several times, from any
+// goroutine, before or while Run is running.
+func (s *Streamer) Close() {
+    s.closeOnce.Do(func() {
+        close(s.closing)
+    })
+}
+
+// Err tells why the stream got closed: nil if all the nodes have been
+// delivered, ErrClosed or the error of the context otherwise. It can be
+// called from any goroutine, but only gives the final answer once the stream
+// is closed.
+func (s *Streamer) Err() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.err
+}
+
+func (s *Streamer) setErr(err error) {
+    s.mu.Lock()
+    s.err = err
+    s.mu.Unlock()
+}
+
+// deliver hands node to the consumer; it returns false if the iteration has
+// to stop instead.
+func (s *Streamer) deliver(ctx context.Context, node *element.Node) bool {
+    if s.stopped(ctx) {
+        return false
+    }
+    select {
+    case s.stream<- node:
+        return true
+    case <-s.closing:
+        s.setErr(ErrClosed)
+    case <-ctx.Done():
+        s.setErr(ctx.Err())
+    }
+    return false
+}
+
+func (s *Streamer) stopped(ctx context.Context) bool {
+    select {
+    case <-s.closing:
+        s.setErr(ErrClosed)
+    case <-ctx.Done():
+        s.setErr(ctx.Err())
+    default:
+        return false
+    }
+    return true
+}
+
+func (s *Streamer) Run(ctx context.Context) error {
+    defer close(s.stream)
+    if s.stopped(ctx) {
+        return s.Err()
+    }
+    for node := range s.it.All() {
+        if !s.deliver(ctx, node) {
+            break
+        }
+    }
+    return s.Err()
+}
@@ -0,0 +1,131 @@
+package iterator_test
+import (
+    "context"
+    "testing"
+    "time"
+    "github.com/yet-another-project/hypergraphdb/element"
+    "github.com/yet-another-project/hypergraphdb/iterator"
+)
+
+func newStreamers(start *element.Node) map[string]*iterator.Streamer {
+    return map[string]*iterator.Streamer{
+        "LinearDFS": iterator.NewStreamer(iterator.NewLinearDFS(start)),
+        "RecursiveDFS": iterator.NewStreamer(iterator.NewRecursiveDFS(start)),
+        "BFS": iterator.NewStreamer(iterator.NewBFS(start)),
+        "IDDFS": iterator.NewStreamer(iterator.NewIDDFS(start)),
+        "Containment": iterator.NewStreamer(iterator.NewContainment(start.Parent(), iterator.PreOrder)),
+    }
+}
+
+// runInBackground starts Run and returns a channel which gets its result.
+func runInBackground(ctx context.Context, run func(context.Context) error) <-chan error {
+    done := make(chan error, 1)
+    go func() {
+        done <- run(ctx)
+    }()
+    return done
+}
+
+func waitForRun(t *testing.T, name string, done <-chan error) error {
+    select {
+    case err := <-done:
+        return err
+    case <-time.After(5 * time.Second):
+        t.Fatal(name, "Run did not return")
+    }
+    return nil
+}
+
+func TestStreamRunsToTheEnd(t *testing.T) {
+    _, start := createChainGraph(10)
+    for name, it := range newStreamers(start) {
+        done := runInBackground(context.Background(), it.Run)
+        count := 0
+        for _ = range it.Stream() {
+            count++
+        }
+        if err := waitForRun(t, name, done); err != nil {
+            t.Error(name, "expected no error, got", err)
+        }
+        if it.Err() != nil {
+            t.Error(name, "expected no error, got", it.Err())
+        }
+        if count < 10 {
+            t.Error(name, "expected at least 10 nodes, got", count)
+        }
+    }
+}
+
+func TestStreamCloseWithoutReading(t *testing.T) {
+    _, start := createChainGraph(10)
+    for name, it := range newStreamers(start) {
+        done := runInBackground(context.Background(), it.Run)
+        <-it.Stream()
+        it.Close()
+        it.Close()
+        if err := waitForRun(t, name, done); err != iterator.ErrClosed {
+            t.Error(name, "expected ErrClosed, got", err)
+        }
+        if it.Err() != iterator.ErrClosed {
+            t.Error(name, "expected ErrClosed, got", it.Err())
+        }
+        if _, open := <-it.Stream(); open {
+            t.Error(name, "stream should have been closed")
+        }
+    }
+}
+
+func TestStreamCloseBeforeRun(t *testing.T) {
+    _, start := createChainGraph(10)
+    for name, it := range newStreamers(start) {
+        it.Close()
+        if err := it.Run(context.Background()); err != iterator.ErrClosed {
+            t.Error(name, "expected ErrClosed, got", err)
+        }
+        if _, open := <-it.Stream(); open {
+            t.Error(name, "stream should have been closed")
+        }
+    }
+}
+
+func TestStreamContextCancel(t *testing.T) {
+    _, start := createChainGraph(10)
+    for name, it := range newStreamers(start) {
+        ctx, cancel := context.WithCancel(context.Background())
+        done := runInBackground(ctx, it.Run)
+        <-it.Stream()
+        cancel()
+        if err := waitForRun(t, name, done); err != context.Canceled {
+            t.Error(name, "expected context.Canceled, got", err)
+        }
+    }
+}
+
+func TestStreamContextDeadline(t *testing.T) {
+    _, start := createChainGraph(10)
+    ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Millisecond)
+    defer cancel()
+    it := iterator.NewStreamer(iterator.NewRecursiveDFS(start))
+    if err := it.Run(ctx); err != context.DeadlineExceeded {
+        t.Error("expected context.DeadlineExceeded, got", err)
+    }
+}
+
+func TestStreamErrWhileRunning(t *testing.T) {
+    _, start := createChainGraph(10)
+    for name, it := range newStreamers(start) {
+        done := runInBackground(context.Background(), it.Run)
+        <-it.Stream()
+        if it.Err() != nil {
+            t.Error(name, "expected no error yet, got", it.Err())
+        }
+        it.Close()
+        for it.Err() == nil {
+            time.Sleep(time.Millisecond)
+        }
+        if it.Err() != iterator.ErrClosed {
+            t.Error(name, "expected ErrClosed, got", it.Err())
+        }
+        waitForRun(t, name, done)
+    }
+}
@@ -1,9 +1,12 @@
 package iterator_test
 import (
     "fmt"
+    "math/rand"
     "github.com/yet-another-project/hypergraphdb/element"
 )
 
+var scalingSizes = []int{1000, 10000, 100000}
+
 func createFullyConnectedGraph(numberOfNodes int) (*element.Node, *element.Node) {
     hypergraph := element.NewGraph("g")
     var subgraph *element.Node
@@ -19,3 +22,32 @@ func createFullyConnectedGraph(numberOfNodes int) (*element.Node, *element.Node)
     }
     return hypergraph, subgraph
 }
+
+// createChainGraph links every node to the next one, the deepest possible DFS.
+func createChainGraph(numberOfNodes int) (*element.Node, *element.Node) {
+    hypergraph := element.NewGraph("g")
+    first := hypergraph.NewSubGraph(fmt.Sprintf("%8d", 0))
+    last := first
+    for nodeid := 1; nodeid < numberOfNodes; nodeid++ {
+        last = last.NewMutualNeighbour(fmt.Sprintf("%8d", nodeid))
+    }
+    return hypergraph, first
+}
+
+// createRandomGraph connects every node to degree random other nodes. The
+// generator is seeded so that all runs see the same graph.
+func createRandomGraph(numberOfNodes int, degree int) (*element.Node, *element.Node) {
+    hypergraph := element.NewGraph("g")
+    random := rand.New(rand.NewSource(int64(numberOfNodes)))
+
+    nodes := make(element.NodeSet, numberOfNodes)
+    for nodeid := range nodes {
+        nodes[nodeid] = hypergraph.NewSubGraph(fmt.Sprintf("%8d", nodeid))
+    }
+    for _, node := range nodes {
+        for i := 0; i < degree; i++ {
+            node.ConnectNeighbour(nodes[random.Intn(numberOfNodes)])
+        }
+    }
+    return hypergraph, nodes[0]
+}